@@ -1,16 +1,23 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Get Bearer Token for CustomAPI
-func (c *Client) GetCustomClientToken() (*CustomAuthResponse, error) {
+func (c *Client) GetCustomClientToken(ctx context.Context) (*CustomAuthResponse, error) {
+	ctx = c.logContext(ctx)
+
 	// Check if credentials provided are empty
 	if c.CustomAuth.Username == "" || c.CustomAuth.Password == "" {
 		return nil, fmt.Errorf("define CustomAPI username and password")
@@ -28,22 +35,24 @@ func (c *Client) GetCustomClientToken() (*CustomAuthResponse, error) {
 	payload := strings.NewReader(data.Encode()) // Convert url.Values to string
 
 	// Initialize the Request client
-	req, err := http.NewRequest(method, url, payload)
+	req, err := http.NewRequestWithContext(ctx, method, url, payload)
 	if err != nil {
-		fmt.Println("Error creating HTTP request:", err)
+		tflog.SubsystemError(ctx, logSubsystemAuth, "Error creating HTTP request", map[string]interface{}{"error": err.Error()})
 		return nil, err
 	}
 
 	// Add Headers for the request
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	fmt.Println("payload is: ", payload)
-	fmt.Println("URL: ", url)
-	fmt.Println("Request Headers: ", req.Header)
+	tflog.SubsystemDebug(ctx, logSubsystemAuth, "Requesting token", map[string]interface{}{
+		"url":       url,
+		"client_id": c.CustomAuth.Username,
+		"payload":   data.Encode(),
+	})
 
 	// Make the request
 	res, err := c.CustomHTTPClient.Do(req)
 	if err != nil {
-		fmt.Println("Error executing HTTP request:", err)
+		tflog.SubsystemError(ctx, logSubsystemAuth, "Error executing HTTP request", map[string]interface{}{"error": err.Error()})
 		return nil, err
 	}
 	defer res.Body.Close()
@@ -51,28 +60,116 @@ func (c *Client) GetCustomClientToken() (*CustomAuthResponse, error) {
 	// Read the Body from the response
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		fmt.Println("Error reading response body:", err)
+		tflog.SubsystemError(ctx, logSubsystemAuth, "Error reading response body", map[string]interface{}{"error": err.Error()})
 		return nil, err
 	}
 
+	tflog.SubsystemDebug(ctx, logSubsystemAuth, "Received token response", map[string]interface{}{
+		"status_code": res.StatusCode,
+		"body":        string(body),
+	})
+
 	// Check status code
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status: %d, body: %s", res.StatusCode, body)
+		return nil, newAPIError(res, body)
 	}
 
 	// Prepare the response as a struct
 	ctAr := CustomAuthResponse{}
 
-	fmt.Println("Response Status Code:", res.StatusCode)
-	fmt.Println("Response Headers:", res.Header)
-	fmt.Printf("Response Body: %s\n", body)
 	// Convert Json response to struct
 	err = json.Unmarshal(body, &ctAr)
 	if err != nil {
-		fmt.Println("Error unmarshalling JSON response:", err)
+		tflog.SubsystemError(ctx, logSubsystemAuth, "Error unmarshalling JSON response", map[string]interface{}{"error": err.Error()})
 		return nil, err
 	}
 
 	// Return the Struct
 	return &ctAr, nil
 }
+
+// tokenExpiryDelta is how long before expiry a token is refreshed, so requests in flight do not race the expiry
+const tokenExpiryDelta = 60 * time.Second
+
+// tokenSource Caches the CustomAPI bearer token and refreshes it before it expires.
+// It is shared by all resource operations and is safe for concurrent use.
+type tokenSource struct {
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	fetch  func(ctx context.Context) (*CustomAuthResponse, error)
+}
+
+// Token returns a valid bearer token, fetching a new one if the cached token is missing or about to expire
+func (ts *tokenSource) Token(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	// A zero expiry means the API did not say, so keep the token until it is rejected
+	if ts.token != "" && (ts.expiry.IsZero() || time.Now().Add(tokenExpiryDelta).Before(ts.expiry)) {
+		return ts.token, nil
+	}
+
+	ctAr, err := ts.fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	ts.token = ctAr.Token
+	ts.expiry = time.Time{}
+	if ctAr.ExpiresIn > 0 {
+		ts.expiry = time.Now().Add(time.Duration(ctAr.ExpiresIn) * time.Second)
+	}
+
+	return ts.token, nil
+}
+
+// invalidate drops the cached token if it is still the one that was rejected,
+// so concurrent callers hitting the same 401 only trigger a single refresh
+func (ts *tokenSource) invalidate(token string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token == token {
+		ts.token = ""
+	}
+}
+
+// do executes an authorized request against the API, refreshing the token and retrying once if it is rejected
+func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	res, err := c.CustomHTTPClient.Do(req)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	// The request body has already been consumed, so it can only be replayed if it can be rebuilt
+	if req.Body != nil && req.GetBody == nil {
+		return res, nil
+	}
+	res.Body.Close()
+
+	// The token was rejected before its expiry, so get a fresh one and try again
+	tflog.SubsystemDebug(ctx, logSubsystemAuth, "Token rejected before expiry, refreshing and retrying request")
+	c.tokens.invalidate(token)
+	token, err = c.tokens.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to rebuild request body: %s", err)
+		}
+	}
+	retry.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	return c.CustomHTTPClient.Do(retry)
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingFetch returns a token fetch which hands out token-1, token-2, ... expiring after expiresIn seconds
+func countingFetch(fetches *int32, expiresIn int) func(ctx context.Context) (*CustomAuthResponse, error) {
+	return func(ctx context.Context) (*CustomAuthResponse, error) {
+		n := atomic.AddInt32(fetches, 1)
+		return &CustomAuthResponse{Token: fmt.Sprintf("token-%d", n), ExpiresIn: expiresIn}, nil
+	}
+}
+
+func TestTokenSourceToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		expiresIn   int
+		wantFetches int32
+	}{
+		{name: "cached until expiry", expiresIn: 3600, wantFetches: 1},
+		{name: "refreshed within expiry delta", expiresIn: int(tokenExpiryDelta/time.Second) - 1, wantFetches: 3},
+		{name: "no expiry kept until rejected", expiresIn: 0, wantFetches: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches int32
+			ts := &tokenSource{fetch: countingFetch(&fetches, tt.expiresIn)}
+
+			for i := 0; i < 3; i++ {
+				if _, err := ts.Token(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := atomic.LoadInt32(&fetches); got != tt.wantFetches {
+				t.Errorf("fetches = %d, want %d", got, tt.wantFetches)
+			}
+		})
+	}
+}
+
+func TestTokenSourceTokenError(t *testing.T) {
+	cause := errors.New("bad credentials")
+	ts := &tokenSource{fetch: func(ctx context.Context) (*CustomAuthResponse, error) {
+		return nil, cause
+	}}
+
+	if _, err := ts.Token(context.Background()); !errors.Is(err, cause) {
+		t.Errorf("err = %v, want it to wrap %v", err, cause)
+	}
+}
+
+func TestTokenSourceInvalidate(t *testing.T) {
+	var fetches int32
+	ts := &tokenSource{fetch: countingFetch(&fetches, 3600)}
+
+	token, _ := ts.Token(context.Background())
+
+	// A stale token from a request which started before a refresh must not drop the new token
+	ts.invalidate("token-0")
+	if got, _ := ts.Token(context.Background()); got != token {
+		t.Errorf("token after stale invalidate = %s, want %s", got, token)
+	}
+
+	ts.invalidate(token)
+	if got, _ := ts.Token(context.Background()); got != "token-2" {
+		t.Errorf("token after invalidate = %s, want token-2", got)
+	}
+}
+
+func TestTokenSourceConcurrent(t *testing.T) {
+	var fetches int32
+	ts := &tokenSource{fetch: countingFetch(&fetches, 3600)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ts.Token(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Errorf("fetches = %d, want 1", got)
+	}
+}
+
+func TestDoRefreshesRejectedToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		rejections  int32
+		wantStatus  int
+		wantFetches int32
+		wantCalls   int32
+	}{
+		{name: "retried once with a new token", rejections: 1, wantStatus: http.StatusOK, wantFetches: 2, wantCalls: 2},
+		{name: "not retried a second time", rejections: 2, wantStatus: http.StatusUnauthorized, wantFetches: 2, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				call := atomic.AddInt32(&calls, 1)
+				if want := fmt.Sprintf("Bearer token-%d", call); r.Header.Get("Authorization") != want {
+					t.Errorf("Authorization = %q, want %q", r.Header.Get("Authorization"), want)
+				}
+				// The body must be replayed on the retry
+				if body, _ := io.ReadAll(r.Body); string(body) != `{"a":"b"}` {
+					t.Errorf("body = %q, want the original payload", body)
+				}
+				if call <= tt.rejections {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+			var fetches int32
+			c.tokens = &tokenSource{fetch: countingFetch(&fetches, 3600)}
+
+			req, _ := http.NewRequest("POST", c.CommerceAPIURL+"/test", strings.NewReader(`{"a":"b"}`))
+			res, err := c.do(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", res.StatusCode, tt.wantStatus)
+			}
+			if got := atomic.LoadInt32(&fetches); got != tt.wantFetches {
+				t.Errorf("fetches = %d, want %d", got, tt.wantFetches)
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// CatalogueProduct Struct for a product in the commerce catalogue
+type CatalogueProduct struct {
+	ProductID string         `json:"productId"`
+	Name      string         `json:"name"`
+	Skus      []CatalogueSku `json:"skus"`
+}
+
+// CatalogueSku Struct for a SKU of a catalogue product
+type CatalogueSku struct {
+	SkuID  string           `json:"skuId"`
+	Name   string           `json:"name"`
+	Prices []CataloguePrice `json:"prices"`
+}
+
+// CataloguePrice Struct for a price a catalogue SKU can be ordered at, its ID is the PriceID of SubscriptionDetails
+type CataloguePrice struct {
+	PriceID          int     `json:"priceId"`
+	BillingFrequency string  `json:"billingFrequency"`
+	Term             string  `json:"term"`
+	UnitPrice        float64 `json:"unitPrice"`
+	Currency         string  `json:"currency"`
+}
+
+// ListCatalogueProductsOptions Filters for the catalogue, any field left empty is not filtered on
+type ListCatalogueProductsOptions struct {
+	ProductID        string
+	SkuID            string
+	BillingFrequency string
+	Term             string
+}
+
+// query returns the filters as query parameters for the catalogue product list
+func (o ListCatalogueProductsOptions) query() url.Values {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"productId":        o.ProductID,
+		"skuId":            o.SkuID,
+		"billingFrequency": o.BillingFrequency,
+		"term":             o.Term,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return query
+}
+
+// ListCatalogueProducts fetches the products the contract can order, with their SKUs and prices, following the pages of the catalogue
+func (c *Client) ListCatalogueProducts(ctx context.Context, opts ListCatalogueProductsOptions) ([]CatalogueProduct, error) {
+	return listPages[CatalogueProduct](ctx, c, fmt.Sprintf("/api/v2/contracts/%d/catalogue/products", c.ContractID), opts.query())
+}
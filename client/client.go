@@ -1,9 +1,9 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 )
@@ -13,14 +13,26 @@ type Client struct {
 	CommerceAPIURL   string
 	CustomHostURL    string
 	CustomHTTPClient *http.Client
-	CustomToken      string
 	CustomAuth       CustomAuthStruct
 	ContractID       int
+	Retry            RetryConfig
+	PollInterval     time.Duration
+	BasketLockFile   string
+	// OnExisting is the provider's on_existing setting, telling resources what to do with a matching existing subscription
+	OnExisting string
+	// AbandonedReportFile is the provider's abandoned_report_file setting, where resources record subscriptions left running on destroy
+	AbandonedReportFile string
+
+	// tokens caches the bearer token and refreshes it before it expires
+	tokens *tokenSource
 }
 
 // CustomHostURL  Default URL, empty one
 const CustomHostURL string = ""
 
+// DefaultPollInterval How often to check an order while waiting for its subscription to be provisioned
+const DefaultPollInterval = 30 * time.Second
+
 // CustomAuthStruct Auth Credentials -
 type CustomAuthStruct struct {
 	Username string `url:"client_id"`
@@ -34,28 +46,50 @@ type CustomAuthResponse struct {
 	ExpiresIn int    `json:"expires_in"`
 }
 
+// OrderDetails Struct for Order Get Request response
 type OrderDetails struct {
-	ID           int    `json:"id"`
-	ContractName string `json:"contractName"`
-	CreateDate   string `json:"createDate"`
-	Items        []struct {
-		SubscriptionID      string `json:"subscriptionId"`
-		PONumber            string `json:"poNumber"`
-		FriendlyName        string `json:"friendlyName"`
-		PrincipalID         string `json:"principalId"`
-		CloudSubscriptionID *int   `json:"cloudSubscriptionId"`
-	} `json:"items"`
+	ID           int         `json:"id"`
+	ContractName string      `json:"contractName"`
+	CreateDate   string      `json:"createDate"`
+	Status       string      `json:"status"`
+	Items        []OrderItem `json:"items"`
+}
+
+// Provisioned reports whether the order has items and every one of them has its subscription ID
+func (o *OrderDetails) Provisioned() bool {
+	if len(o.Items) == 0 {
+		return false
+	}
+	for _, item := range o.Items {
+		if item.SubscriptionID == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// OrderItem Struct for a single item within an order
+type OrderItem struct {
+	SubscriptionID      string `json:"subscriptionId"`
+	PONumber            string `json:"poNumber"`
+	FriendlyName        string `json:"friendlyName"`
+	PrincipalID         string `json:"principalId"`
+	CloudSubscriptionID *int   `json:"cloudSubscriptionId"`
+	DivisionID          *int   `json:"divisionId"`
 }
 
 // NewClient Initialize a new Client
-func NewClient(identity_api_url, commerce_api_url, username, password *string, contract_id int) (*Client, error) {
+func NewClient(ctx context.Context, identity_api_url, commerce_api_url, username, password *string, contract_id int) (*Client, error) {
 
 	// Initialize the client
 	client := Client{
 		CustomHTTPClient: &http.Client{Timeout: 120 * time.Second},
 		// Set Default URLs
 		CustomHostURL: CustomHostURL,
+		Retry:         DefaultRetryConfig,
+		PollInterval:  DefaultPollInterval,
 	}
+	client.tokens = &tokenSource{fetch: client.GetCustomClientToken}
 
 	// Add CommerceAPIURL for Custom client
 	if commerce_api_url != nil {
@@ -81,40 +115,20 @@ func NewClient(identity_api_url, commerce_api_url, username, password *string, c
 		Password: *password,
 	}
 
-	// Get The token for Custom Client
-	ctt, err := client.GetCustomClientToken()
+	// Get the first token for Custom Client so bad credentials fail at configure time
+	_, err := client.tokens.Token(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set the received token to the CustomClient
-	client.CustomToken = ctt.Token
-
 	// Return the client
 	return &client, nil
 }
 
 // GetOrderDetails fetches the details of an order
-func (c *Client) GetOrderDetails(orderID string) (*OrderDetails, error) {
+func (c *Client) GetOrderDetails(ctx context.Context, orderID string) (*OrderDetails, error) {
 	url := fmt.Sprintf("%s/api/v2/contracts/%d/orders/%s", c.CommerceAPIURL, c.ContractID, orderID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %s", err)
-	}
-
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.CustomToken))
-	res, err := c.CustomHTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %s", err)
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(res.Body)
-		return nil, fmt.Errorf("unexpected HTTP status code: %d, response body: %s", res.StatusCode, string(bodyBytes))
-	}
-
-	body, err := io.ReadAll(res.Body)
+	body, err := c.doRequest(ctx, "GET", url, nil, true)
 	if err != nil {
 		return nil, err
 	}
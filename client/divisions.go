@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Division Struct for a billing division of the contract
+type Division struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// GetDivisions fetches the billing divisions of the contract
+func (c *Client) GetDivisions(ctx context.Context) ([]Division, error) {
+	url := fmt.Sprintf("%s/api/v2/contracts/%d/divisions", c.CommerceAPIURL, c.ContractID)
+	bodyBytes, err := c.doRequest(ctx, "GET", url, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var divisions []Division
+	err = json.Unmarshal(bodyBytes, &divisions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response body: %s", err)
+	}
+
+	return divisions, nil
+}
+
+// ValidateDivision checks the division belongs to the contract, so charges cannot land on an unknown division
+func (c *Client) ValidateDivision(ctx context.Context, divisionID int) error {
+	divisions, err := c.GetDivisions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get divisions of contract: %w", err)
+	}
+
+	valid := make([]string, len(divisions))
+	for i, division := range divisions {
+		if division.ID == divisionID {
+			return nil
+		}
+		valid[i] = fmt.Sprintf("%d (%s)", division.ID, division.Name)
+	}
+
+	return fmt.Errorf("division %d does not belong to contract %d, valid divisions are: %s", divisionID, c.ContractID, strings.Join(valid, ", "))
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// APIError Error returned when the Commerce API responds with an unsuccessful status code
+type APIError struct {
+	StatusCode int
+	Method     string
+	Endpoint   string
+	RequestID  string
+	Problem    *ProblemDetails
+	Body       string
+}
+
+// ProblemDetails Problem details JSON body returned by the API on errors
+type ProblemDetails struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail"`
+	Instance string              `json:"instance"`
+	TraceID  string              `json:"traceId"`
+	Errors   map[string][]string `json:"errors"`
+}
+
+// newAPIError builds an APIError from an unsuccessful response and its body
+func newAPIError(res *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		Method:     res.Request.Method,
+		Endpoint:   res.Request.URL.Path,
+		RequestID:  res.Header.Get("X-Request-Id"),
+		Body:       string(body),
+	}
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = res.Header.Get("Request-Id")
+	}
+
+	// Not every endpoint returns problem details, so keep the raw body when it cannot be parsed
+	var problem ProblemDetails
+	if err := json.Unmarshal(body, &problem); err == nil && (problem.Title != "" || problem.Detail != "" || len(problem.Errors) > 0) {
+		apiErr.Problem = &problem
+		if apiErr.RequestID == "" {
+			apiErr.RequestID = problem.TraceID
+		}
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: unexpected HTTP status code: %d", e.Method, e.Endpoint, e.StatusCode)
+
+	if e.Problem != nil {
+		if e.Problem.Title != "" {
+			msg += ", " + e.Problem.Title
+		}
+		if e.Problem.Detail != "" {
+			msg += ": " + e.Problem.Detail
+		}
+		// Sort the fields so the message is the same every time
+		fields := make([]string, 0, len(e.Problem.Errors))
+		for field := range e.Problem.Errors {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			msg += fmt.Sprintf(", %s: %s", field, strings.Join(e.Problem.Errors[field], " "))
+		}
+	} else if e.Body != "" {
+		msg += ", response body: " + e.Body
+	}
+
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request ID: %s)", e.RequestID)
+	}
+	return msg
+}
+
+// IsNotFound reports whether err is an APIError for an object which does not exist
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is an APIError for a request which conflicts with the current state
+func IsConflict(err error) bool {
+	return hasStatusCode(err, http.StatusConflict)
+}
+
+// IsUnauthorized reports whether err is an APIError for rejected credentials
+func IsUnauthorized(err error) bool {
+	return hasStatusCode(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is an APIError for a request the credentials are not allowed to make
+func IsForbidden(err error) bool {
+	return hasStatusCode(err, http.StatusForbidden)
+}
+
+func hasStatusCode(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
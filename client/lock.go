@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// basketLockPollInterval How often to try the basket lock file while another process holds it
+const basketLockPollInterval = 500 * time.Millisecond
+
+// The Commerce API keeps a single open basket per contract, so basket-add through checkout must not
+// overlap between resources. contractLocks holds one lock per contract, shared by every Client in the process.
+var (
+	contractLocksMu sync.Mutex
+	contractLocks   = map[string]chan struct{}{}
+)
+
+// contractLock returns the lock for the client's contract, creating it on first use
+func (c *Client) contractLock() chan struct{} {
+	key := fmt.Sprintf("%s/%d", c.CommerceAPIURL, c.ContractID)
+
+	contractLocksMu.Lock()
+	defer contractLocksMu.Unlock()
+
+	lock, ok := contractLocks[key]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		contractLocks[key] = lock
+	}
+	return lock
+}
+
+// lockBasket waits until this client has the contract basket to itself, also taking BasketLockFile when set
+// so separate Terraform runs are serialized too. The returned function releases the lock.
+func (c *Client) lockBasket(ctx context.Context) (func(), error) {
+	lock := c.contractLock()
+
+	tflog.SubsystemDebug(ctx, logSubsystemCommerce, "Waiting for contract basket lock", map[string]interface{}{"contract_id": c.ContractID})
+	select {
+	case lock <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("stopped waiting for contract basket lock: %w", ctx.Err())
+	}
+
+	if c.BasketLockFile == "" {
+		return func() { <-lock }, nil
+	}
+
+	file, err := c.lockBasketFile(ctx)
+	if err != nil {
+		<-lock
+		return nil, err
+	}
+
+	return func() {
+		unlockFile(file)
+		file.Close()
+		<-lock
+	}, nil
+}
+
+// lockBasketFile takes an exclusive lock on BasketLockFile, polling until it is free
+func (c *Client) lockBasketFile(ctx context.Context) (*os.File, error) {
+	file, err := os.OpenFile(c.BasketLockFile, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open basket lock file: %s", err)
+	}
+
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock basket lock file %s: %s", c.BasketLockFile, err)
+		}
+		if locked {
+			return file, nil
+		}
+
+		tflog.SubsystemDebug(ctx, logSubsystemCommerce, "Basket lock file is held by another process, waiting", map[string]interface{}{"path": c.BasketLockFile})
+		if err := sleepContext(ctx, basketLockPollInterval); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("stopped waiting for basket lock file %s: %w", c.BasketLockFile, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Log subsystems, their levels follow TF_LOG and can be overridden with
+// TF_LOG_PROVIDER_BYTESNEW_AUTH and TF_LOG_PROVIDER_BYTESNEW_COMMERCE
+const (
+	logSubsystemAuth     = "auth"
+	logSubsystemCommerce = "commerce"
+)
+
+// logMaskedFieldKeys Log fields which always hold credentials
+var logMaskedFieldKeys = []string{"client_secret", "access_token", "Authorization"}
+
+// logMaskedPatterns Credentials which may appear inside log messages, payloads and response bodies
+var logMaskedPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-._~+/]+=*`),
+	regexp.MustCompile(`client_secret=[^&\s]*`),
+	regexp.MustCompile(`"access_token"\s*:\s*"[^"]*"`),
+}
+
+// logContext adds the auth and commerce log subsystems to ctx, masking credentials in everything they log
+func (c *Client) logContext(ctx context.Context) context.Context {
+	for _, subsystem := range []string{logSubsystemAuth, logSubsystemCommerce} {
+		ctx = tflog.NewSubsystem(ctx, subsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_BYTESNEW", subsystem))
+		ctx = tflog.SubsystemMaskFieldValuesWithFieldKeys(ctx, subsystem, logMaskedFieldKeys...)
+		ctx = tflog.SubsystemMaskLogRegexes(ctx, subsystem, logMaskedPatterns...)
+		if c.CustomAuth.Password != "" {
+			ctx = tflog.SubsystemMaskLogStrings(ctx, subsystem, c.CustomAuth.Password)
+		}
+	}
+	return ctx
+}
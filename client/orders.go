@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ListOrdersOptions Filters for the order list, any field left empty is not filtered on
+type ListOrdersOptions struct {
+	// CreatedFrom and CreatedTo limit the orders to those created within the range, as ISO 8601 dates or times
+	CreatedFrom string
+	CreatedTo   string
+	PONumber    string
+	Status      string
+}
+
+// query returns the filters as query parameters for the order list
+func (o ListOrdersOptions) query() url.Values {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"createdFrom": o.CreatedFrom,
+		"createdTo":   o.CreatedTo,
+		"poNumber":    o.PONumber,
+		"status":      o.Status,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return query
+}
+
+// ListOrders fetches every order placed on the contract which matches opts, following the pages of the order list
+func (c *Client) ListOrders(ctx context.Context, opts ListOrdersOptions) ([]OrderDetails, error) {
+	return listPages[OrderDetails](ctx, c, fmt.Sprintf("/api/v2/contracts/%d/orders", c.ContractID), opts.query())
+}
+
+// OrderItemMatch An order item together with the order holding it
+type OrderItemMatch struct {
+	Order OrderDetails
+	Item  OrderItem
+}
+
+// FindOrderItems returns every order item on the contract for a subscription with the given friendly name and PO number.
+// Order items keep the PO number a subscription was ordered with, which can since have been changed in place, so
+// matches are checked against the current PO number of their subscription, and subscriptions moved onto poNumber
+// after they were ordered are found through the subscription list. Subscriptions which have been deleted or cancelled
+// are left out, as they cannot be adopted and do not stop another being ordered.
+// Friendly names are compared case-insensitively, as the portal does.
+func (c *Client) FindOrderItems(ctx context.Context, friendlyName, poNumber string) ([]OrderItemMatch, error) {
+	orders, err := c.ListOrders(ctx, ListOrdersOptions{PONumber: poNumber})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	subscriptions, err := c.ListSubscriptions(ctx, ListSubscriptionsOptions{PONumber: poNumber})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	// Subscriptions currently on poNumber, keyed by lower case ID as subscription IDs are compared case-insensitively
+	listed := map[string]Subscription{}
+	for _, subscription := range subscriptions {
+		if strings.EqualFold(subscription.FriendlyName, friendlyName) && subscription.PONumber == poNumber {
+			listed[strings.ToLower(subscription.SubscriptionID)] = subscription
+		}
+	}
+
+	var matches []OrderItemMatch
+	found := map[string]bool{}
+	for _, order := range orders {
+		for _, item := range order.Items {
+			if !strings.EqualFold(item.FriendlyName, friendlyName) || item.PONumber != poNumber {
+				continue
+			}
+			if id := strings.ToLower(item.SubscriptionID); id != "" {
+				subscription, ok := listed[id]
+				if !ok {
+					// Not listed under poNumber, so check whether it still exists and whether its PO number has been changed since it was ordered
+					got, err := c.GetSubscription(ctx, item.SubscriptionID)
+					if IsNotFound(err) {
+						continue
+					}
+					if err != nil {
+						return nil, fmt.Errorf("failed to get subscription %s: %w", item.SubscriptionID, err)
+					}
+					if got.PONumber != poNumber {
+						continue
+					}
+					subscription = *got
+				}
+				if subscription.Cancelled() {
+					continue
+				}
+				found[id] = true
+			}
+			matches = append(matches, OrderItemMatch{Order: order, Item: item})
+		}
+	}
+
+	for _, subscription := range subscriptions {
+		id := strings.ToLower(subscription.SubscriptionID)
+		if _, ok := listed[id]; !ok || found[id] || subscription.Cancelled() {
+			continue
+		}
+		found[id] = true
+
+		order, err := c.GetOrderDetails(ctx, strconv.Itoa(subscription.OrderID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get order %d: %w", subscription.OrderID, err)
+		}
+		matches = append(matches, OrderItemMatch{Order: *order, Item: OrderItem{
+			SubscriptionID: subscription.SubscriptionID,
+			PONumber:       subscription.PONumber,
+			FriendlyName:   subscription.FriendlyName,
+			PrincipalID:    subscription.PrincipalID,
+			DivisionID:     subscription.DivisionID,
+		}})
+	}
+	return matches, nil
+}
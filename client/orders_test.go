@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestFindOrderItemsUsesCurrentPONumber(t *testing.T) {
+	// Order 1 was placed on PO-1 and has since been moved to PO-2, order 2 was placed on PO-2 and moved to PO-1
+	orders := map[string]OrderDetails{
+		"1": {ID: 1, Items: []OrderItem{{SubscriptionID: "sub-1", FriendlyName: "examplesub", PONumber: "PO-1"}}},
+		"2": {ID: 2, Items: []OrderItem{{SubscriptionID: "sub-2", FriendlyName: "examplesub", PONumber: "PO-2"}}},
+	}
+	subscriptions := map[string]Subscription{
+		"sub-1": {SubscriptionID: "sub-1", OrderID: 1, FriendlyName: "examplesub", PONumber: "PO-2"},
+		"sub-2": {SubscriptionID: "SUB-2", OrderID: 2, FriendlyName: "ExampleSub", PONumber: "PO-1"},
+	}
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		poNumber := r.URL.Query().Get("poNumber")
+		switch r.URL.Path {
+		case "/api/v2/contracts/1/orders":
+			var page Page[OrderDetails]
+			for _, order := range orders {
+				if order.Items[0].PONumber == poNumber {
+					page.Items = append(page.Items, order)
+				}
+			}
+			json.NewEncoder(w).Encode(page)
+		case "/api/v2/contracts/1/subscriptions":
+			var page Page[Subscription]
+			for _, subscription := range subscriptions {
+				if subscription.PONumber == poNumber {
+					page.Items = append(page.Items, subscription)
+				}
+			}
+			json.NewEncoder(w).Encode(page)
+		case "/api/v2/subscriptions/sub-1":
+			json.NewEncoder(w).Encode(subscriptions["sub-1"])
+		case "/api/v2/contracts/1/orders/2":
+			json.NewEncoder(w).Encode(orders["2"])
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	matches, err := c.FindOrderItems(context.Background(), "examplesub", "PO-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("matches = %+v, want only order 2", matches)
+	}
+	if match := matches[0]; match.Order.ID != 2 || match.Item.SubscriptionID != "SUB-2" || match.Item.PONumber != "PO-1" {
+		t.Errorf("match = %+v, want order 2 holding SUB-2 on PO-1", match)
+	}
+}
+
+func TestFindOrderItemsSkipsDeadSubscriptions(t *testing.T) {
+	// sub-1 has been deleted, sub-2 is cancelled but still listed and sub-3 was cancelled after it was moved off the list
+	orders := []OrderDetails{
+		{ID: 1, Items: []OrderItem{{SubscriptionID: "sub-1", FriendlyName: "examplesub", PONumber: "PO-1"}}},
+		{ID: 2, Items: []OrderItem{{SubscriptionID: "sub-2", FriendlyName: "examplesub", PONumber: "PO-1"}}},
+		{ID: 3, Items: []OrderItem{{SubscriptionID: "sub-3", FriendlyName: "examplesub", PONumber: "PO-1"}}},
+		{ID: 4, Items: []OrderItem{{SubscriptionID: "sub-4", FriendlyName: "examplesub", PONumber: "PO-1"}}},
+	}
+	subscriptions := []Subscription{
+		{SubscriptionID: "sub-2", OrderID: 2, FriendlyName: "examplesub", PONumber: "PO-1", Status: SubscriptionStatusCancelled},
+		{SubscriptionID: "sub-4", OrderID: 4, FriendlyName: "examplesub", PONumber: "PO-1", Status: "Active"},
+		{SubscriptionID: "sub-5", OrderID: 5, FriendlyName: "examplesub", PONumber: "PO-1", Status: SubscriptionStatusDisabled},
+	}
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/contracts/1/orders":
+			json.NewEncoder(w).Encode(Page[OrderDetails]{Items: orders})
+		case "/api/v2/contracts/1/subscriptions":
+			json.NewEncoder(w).Encode(Page[Subscription]{Items: subscriptions})
+		case "/api/v2/subscriptions/sub-1":
+			w.WriteHeader(http.StatusNotFound)
+		case "/api/v2/subscriptions/sub-3":
+			json.NewEncoder(w).Encode(Subscription{SubscriptionID: "sub-3", FriendlyName: "examplesub", PONumber: "PO-1", Status: SubscriptionStatusPendingCancellation})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	matches, err := c.FindOrderItems(context.Background(), "examplesub", "PO-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Item.SubscriptionID != "sub-4" {
+		t.Errorf("matches = %+v, want only the live sub-4", matches)
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// listPageSize Number of results requested per page when listing orders, subscriptions or catalogue products
+const listPageSize = 100
+
+// Page Struct for a single page of a list response
+type Page[T any] struct {
+	Items      []T `json:"items"`
+	Page       int `json:"page"`
+	PageSize   int `json:"pageSize"`
+	TotalCount int `json:"totalCount"`
+}
+
+// listPages fetches the list at path, which is relative to the commerce API, filtered by query and following its pages
+// until a short page or the total reported by the API has been read
+func listPages[T any](ctx context.Context, c *Client, path string, query url.Values) ([]T, error) {
+	query.Set("pageSize", strconv.Itoa(listPageSize))
+
+	var items []T
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+		url := fmt.Sprintf("%s%s?%s", c.CommerceAPIURL, path, query.Encode())
+		body, err := c.doRequest(ctx, "GET", url, nil, true)
+		if err != nil {
+			return nil, err
+		}
+
+		var listPage Page[T]
+		err = json.Unmarshal(body, &listPage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response body: %s", err)
+		}
+		items = append(items, listPage.Items...)
+
+		if len(listPage.Items) < listPageSize || (listPage.TotalCount > 0 && len(items) >= listPage.TotalCount) {
+			return items, nil
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestListPages(t *testing.T) {
+	tests := []struct {
+		name       string
+		total      int
+		reportAll  bool
+		wantPages  int32
+		wantLength int
+	}{
+		{name: "stops on a short page", total: listPageSize + 1, wantPages: 2, wantLength: listPageSize + 1},
+		{name: "stops at the reported total", total: 2 * listPageSize, reportAll: true, wantPages: 2, wantLength: 2 * listPageSize},
+		{name: "reads a further empty page without a total", total: listPageSize, wantPages: 2, wantLength: listPageSize},
+		{name: "empty list", total: 0, wantPages: 1, wantLength: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pages int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&pages, 1)
+				if got := r.URL.Query().Get("status"); got != "active" {
+					t.Errorf("status = %q, want the query passed through on every page", got)
+				}
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+				var listPage Page[int]
+				for i := (page - 1) * listPageSize; i < tt.total && i < page*listPageSize; i++ {
+					listPage.Items = append(listPage.Items, i)
+				}
+				if tt.reportAll {
+					listPage.TotalCount = tt.total
+				}
+				json.NewEncoder(w).Encode(listPage)
+			})
+
+			items, err := listPages[int](context.Background(), c, "/test", map[string][]string{"status": {"active"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != tt.wantLength {
+				t.Errorf("items = %d, want %d", len(items), tt.wantLength)
+			}
+			if got := atomic.LoadInt32(&pages); got != tt.wantPages {
+				t.Errorf("pages = %d, want %d", got, tt.wantPages)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// RetryConfig Retry settings for Commerce API requests
+type RetryConfig struct {
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryConfig Retry settings used when the provider does not override them
+var DefaultRetryConfig = RetryConfig{
+	MaxRetries: 4,
+	MinBackoff: 1 * time.Second,
+	MaxBackoff: 30 * time.Second,
+}
+
+// doRequest sends a request to the Commerce API and returns the body of a successful response.
+// The payload is sent as JSON when it is not nil. Transient failures are retried with exponential backoff:
+// idempotent requests are retried on transport errors, 429 and 5xx responses, while other requests are
+// only retried on 429 where the API has rejected them before doing any work.
+func (c *Client) doRequest(ctx context.Context, method string, url string, payload interface{}, idempotent bool) ([]byte, error) {
+	ctx = c.logContext(ctx)
+
+	var data []byte
+	if payload != nil {
+		var err error
+		data, err = json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %s", err)
+		}
+	}
+
+	var attempts []string
+	for attempt := 0; ; attempt++ {
+		bodyBytes, retryAfter, err := c.doAttempt(ctx, method, url, data, idempotent)
+		if err == nil {
+			return bodyBytes, nil
+		}
+
+		attempts = append(attempts, err.Error())
+		retryable, ok := err.(*retryableError)
+		if !ok || attempt >= c.Retry.MaxRetries {
+			return nil, attemptsError(err, attempts)
+		}
+
+		wait := c.Retry.backoff(attempt)
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		tflog.SubsystemWarn(ctx, logSubsystemCommerce, "Retrying request", map[string]interface{}{
+			"method":  method,
+			"url":     url,
+			"attempt": attempt + 1,
+			"wait":    wait.String(),
+			"error":   retryable.err.Error(),
+		})
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, attemptsError(fmt.Errorf("%w while waiting to retry", err), attempts)
+		}
+	}
+}
+
+// sleepContext waits for the given duration, returning early with the context error if it is cancelled or times out
+func sleepContext(ctx context.Context, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// doAttempt makes a single attempt at a request, returning how long the API asked us to wait if it was throttled
+func (c *Client) doAttempt(ctx context.Context, method string, url string, data []byte, idempotent bool) ([]byte, time.Duration, error) {
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %s", err)
+	}
+	if data != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	tflog.SubsystemDebug(ctx, logSubsystemCommerce, "Sending request", map[string]interface{}{
+		"method":  method,
+		"url":     url,
+		"payload": string(data),
+	})
+
+	res, err := c.do(ctx, req)
+	if err != nil {
+		// A cancelled or timed out context is not worth retrying
+		err = fmt.Errorf("failed to execute request: %w", err)
+		if idempotent && ctx.Err() == nil {
+			return nil, 0, &retryableError{err: err}
+		}
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+
+	bodyBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response body: %s", err)
+	}
+
+	tflog.SubsystemDebug(ctx, logSubsystemCommerce, "Received response", map[string]interface{}{
+		"method":      method,
+		"url":         url,
+		"status_code": res.StatusCode,
+		"body":        string(bodyBytes),
+	})
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return bodyBytes, 0, nil
+	}
+
+	apiErr := newAPIError(res, bodyBytes)
+	if res.StatusCode == http.StatusTooManyRequests || (idempotent && res.StatusCode >= 500) {
+		return nil, parseRetryAfter(res.Header.Get("Retry-After")), &retryableError{err: apiErr}
+	}
+	return nil, 0, apiErr
+}
+
+// retryableError Marks a failed attempt as safe to try again
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// attemptsError adds the outcome of every attempt to the final error when a request was retried
+func attemptsError(err error, attempts []string) error {
+	if retryable, ok := err.(*retryableError); ok {
+		err = retryable.err
+	}
+	if len(attempts) <= 1 {
+		return err
+	}
+
+	history := make([]string, len(attempts))
+	for i, attempt := range attempts {
+		history[i] = fmt.Sprintf("attempt %d: %s", i+1, attempt)
+	}
+	return fmt.Errorf("%w (gave up after %d attempts: %s)", err, len(attempts), strings.Join(history, "; "))
+}
+
+// backoff returns the exponential backoff with jitter to wait before the next attempt
+func (r RetryConfig) backoff(attempt int) time.Duration {
+	wait := r.MinBackoff
+	for i := 0; i < attempt && wait < r.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > r.MaxBackoff {
+		wait = r.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+
+	// Spread the retries of parallel resources so they do not hit the API at the same moment
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
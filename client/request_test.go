@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a Client for a test server, with a fixed token and retries short enough for tests
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c := &Client{
+		CommerceAPIURL:   server.URL,
+		CustomHTTPClient: server.Client(),
+		ContractID:       1,
+		Retry:            RetryConfig{MaxRetries: 2, MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond},
+		PollInterval:     time.Millisecond,
+	}
+	c.tokens = &tokenSource{fetch: func(ctx context.Context) (*CustomAuthResponse, error) {
+		return &CustomAuthResponse{Token: "test-token"}, nil
+	}}
+	return c
+}
+
+func TestRetryConfigBackoff(t *testing.T) {
+	r := RetryConfig{MinBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	tests := []struct {
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{attempt: 0, min: 500 * time.Millisecond, max: time.Second},
+		{attempt: 1, min: time.Second, max: 2 * time.Second},
+		{attempt: 2, min: 2 * time.Second, max: 4 * time.Second},
+		{attempt: 3, min: 4 * time.Second, max: 8 * time.Second},
+		{attempt: 4, min: 5 * time.Second, max: 10 * time.Second},
+		{attempt: 50, min: 5 * time.Second, max: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if wait := r.backoff(tt.attempt); wait < tt.min || wait > tt.max {
+				t.Errorf("backoff(%d) = %s, want between %s and %s", tt.attempt, wait, tt.min, tt.max)
+			}
+		}
+	}
+
+	if wait := (RetryConfig{}).backoff(3); wait != 0 {
+		t.Errorf("backoff with no backoff configured = %s, want 0", wait)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{name: "empty", value: "", min: 0, max: 0},
+		{name: "seconds", value: "7", min: 7 * time.Second, max: 7 * time.Second},
+		{name: "zero seconds", value: "0", min: 0, max: 0},
+		{name: "negative seconds", value: "-3", min: 0, max: 0},
+		{name: "http date", value: time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat), min: 28 * time.Second, max: 30 * time.Second},
+		{name: "http date in the past", value: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), min: 0, max: 0},
+		{name: "invalid", value: "soon", min: 0, max: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if wait := parseRetryAfter(tt.value); wait < tt.min || wait > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %s, want between %s and %s", tt.value, wait, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestAttemptsError(t *testing.T) {
+	cause := errors.New("boom")
+
+	if err := attemptsError(&retryableError{err: cause}, []string{"boom"}); err != cause {
+		t.Errorf("single attempt: got %v, want the unwrapped cause", err)
+	}
+
+	err := attemptsError(&retryableError{err: cause}, []string{"first", "boom"})
+	if !errors.Is(err, cause) {
+		t.Errorf("retried: %v does not wrap the cause", err)
+	}
+	if want := "boom (gave up after 2 attempts: attempt 1: first; attempt 2: boom)"; err.Error() != want {
+		t.Errorf("retried: got %q, want %q", err.Error(), want)
+	}
+}
+
+func TestDoRequestRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		idempotent   bool
+		statuses     []int
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{name: "idempotent retried on 5xx", method: "GET", idempotent: true, statuses: []int{503, 502, 200}, wantAttempts: 3},
+		{name: "idempotent gives up after max retries", method: "GET", idempotent: true, statuses: []int{500, 500, 500, 500}, wantAttempts: 3, wantErr: true},
+		{name: "idempotent not retried on 4xx", method: "GET", idempotent: true, statuses: []int{404}, wantAttempts: 1, wantErr: true},
+		{name: "non-idempotent not retried on 5xx", method: "POST", statuses: []int{500, 200}, wantAttempts: 1, wantErr: true},
+		{name: "non-idempotent retried on 429", method: "POST", statuses: []int{429, 200}, wantAttempts: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				attempt := atomic.AddInt32(&attempts, 1)
+				if r.Method != tt.method {
+					t.Errorf("method = %s, want %s", r.Method, tt.method)
+				}
+				w.WriteHeader(tt.statuses[attempt-1])
+				w.Write([]byte(`{}`))
+			})
+
+			_, err := c.doRequest(context.Background(), tt.method, c.CommerceAPIURL+"/test", nil, tt.idempotent)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestDoRequestTransportErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		idempotent   bool
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{name: "idempotent retried", method: "GET", idempotent: true, wantAttempts: 2},
+		{name: "non-idempotent not retried", method: "POST", wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				// Drop the connection on the first attempt, so the request may or may not have been processed
+				if atomic.AddInt32(&attempts, 1) == 1 {
+					conn, _, err := w.(http.Hijacker).Hijack()
+					if err != nil {
+						t.Fatal(err)
+					}
+					conn.Close()
+					return
+				}
+				w.Write([]byte(`{}`))
+			})
+
+			_, err := c.doRequest(context.Background(), tt.method, c.CommerceAPIURL+"/test", map[string]string{"a": "b"}, tt.idempotent)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestDoAttemptRetryAfter(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	_, retryAfter, err := c.doAttempt(context.Background(), "POST", c.CommerceAPIURL+"/test", nil, false)
+	if _, ok := err.(*retryableError); !ok {
+		t.Fatalf("err = %v, want a retryableError", err)
+	}
+	if retryAfter != 2*time.Second {
+		t.Errorf("retryAfter = %s, want 2s", retryAfter)
+	}
+}
+
+func TestDoRequestReturnsAPIError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := c.doRequest(context.Background(), "GET", c.CommerceAPIURL+"/test", nil, true)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want an APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.RequestID != "req-1" {
+		t.Errorf("APIError = %+v, want status 503 and request ID req-1", apiErr)
+	}
+	if !strings.Contains(err.Error(), "gave up after 3 attempts") {
+		t.Errorf("err = %q, want the attempt history", err.Error())
+	}
+}
+
+func TestDoRequestStopsWhenCancelled(t *testing.T) {
+	var attempts int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c.Retry = RetryConfig{MaxRetries: 5, MinBackoff: time.Hour, MaxBackoff: time.Hour}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.doRequest(ctx, "GET", c.CommerceAPIURL+"/test", nil, true)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestAPIErrorFieldOrder(t *testing.T) {
+	err := &APIError{
+		Method:     "POST",
+		Endpoint:   "/baskets",
+		StatusCode: http.StatusBadRequest,
+		Problem: &ProblemDetails{
+			Title: "Validation failed",
+			Errors: map[string][]string{
+				"poNumber":     {"is required"},
+				"budgetCode":   {"is too long", "is invalid"},
+				"friendlyName": {"is taken"},
+			},
+		},
+	}
+
+	want := "POST /baskets: unexpected HTTP status code: 400, Validation failed, " +
+		"budgetCode: is too long is invalid, friendlyName: is taken, poNumber: is required"
+	for i := 0; i < 20; i++ {
+		if got := err.Error(); got != want {
+			t.Fatalf("Error() = %q, want %q", got, want)
+		}
+	}
+}
@@ -1,32 +1,201 @@
 package client
 
 import (
-	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Catalogue item ordered when SubscriptionDetails does not say otherwise, an Azure plan entitlement
+const (
+	DefaultProductID        = "ENTITLEMENT"
+	DefaultSkuID            = "ENTITLEMENT"
+	DefaultPriceID          = 24492277
+	DefaultBillingFrequency = "monthly"
+	DefaultTerm             = "Perpetual"
+	DefaultQuantity         = 1
 )
 
 // SubscriptionDetails Struct for creating a subscription
 type SubscriptionDetails struct {
-	FriendlyName string
-	PrincipalID  string
-	PONumber     string
-	BudgetCode   string
-	DivisionID   int
+	FriendlyName     string
+	PrincipalID      string
+	PONumber         string
+	BudgetCode       string
+	DivisionID       int
+	ProductID        string
+	SkuID            string
+	PriceID          int
+	BillingFrequency string
+	Term             string
+	Quantity         int
+}
+
+// Subscription Struct for Subscription Get Request response
+type Subscription struct {
+	SubscriptionID string `json:"subscriptionId"`
+	OrderID        int    `json:"orderId"`
+	FriendlyName   string `json:"friendlyName"`
+	PONumber       string `json:"poNumber"`
+	PrincipalID    string `json:"principalId"`
+	BudgetCode     string `json:"budgetCode"`
+	DivisionID     *int   `json:"divisionId"`
+	Status         string `json:"status"`
+	CreateDate     string `json:"createDate"`
+	// ResourceCount is the number of Azure resources in the subscription, nil when Bytes could not count them
+	ResourceCount *int `json:"resourceCount"`
+}
+
+// Statuses of a subscription which Bytes has accepted a cancellation for
+const (
+	SubscriptionStatusPendingCancellation = "PendingCancellation"
+	SubscriptionStatusCancelled           = "Cancelled"
+	SubscriptionStatusDisabled            = "Disabled"
+)
+
+// Cancelled reports whether Bytes has acknowledged the cancellation of the subscription, even if it has not taken effect yet
+func (s *Subscription) Cancelled() bool {
+	for _, status := range []string{SubscriptionStatusPendingCancellation, SubscriptionStatusCancelled, SubscriptionStatusDisabled} {
+		if strings.EqualFold(s.Status, status) {
+			return true
+		}
+	}
+	return false
 }
 
 // Basket Struct for Basket Post Request response
 type BasketDetails struct {
-	ID    int `json:"id"`
-	Items []struct {
-		ID          int    `json:"id"`
-		PONumber    string `json:"poNumber"`
-		PrincipalID string `json:"principalId"`
-		BudgetCode  string `json:"budgetCode"`
-	} `json:"items"`
+	ID    int          `json:"id"`
+	Items []BasketItem `json:"items"`
+}
+
+// BasketItem Struct for a single item within the basket
+type BasketItem struct {
+	ID                int    `json:"id"`
+	FriendlyName      string `json:"friendlyName"`
+	PONumber          string `json:"poNumber"`
+	PrincipalID       string `json:"principalId"`
+	BudgetCode        string `json:"budgetCode"`
+	DivisionID        *int   `json:"divisionId"`
+	ExternalReference string `json:"externalReference"`
+}
+
+// basketItemTagPrefix Marks the external reference of basket items added by the provider, it is followed by the
+// Unix time the order started and a random suffix
+const basketItemTagPrefix = "terraform-provider-bytesnew:"
+
+// basketLeftoverAge is how old the tag of another run's item must be before it is treated as a leftover. The basket lock
+// file only excludes runs on the same machine, so a newer item may belong to an order still running elsewhere
+const basketLeftoverAge = time.Hour
+
+// basketOrder The subscriptions one order has added to the basket so far, the tag their items were added with,
+// and the IDs of the items, which are what a failed order rolls back
+type basketOrder struct {
+	tag     string
+	added   []SubscriptionDetails
+	itemIDs []int
+	// seen holds the IDs of the items in the basket returned when the order last added an item
+	seen map[int]bool
+}
+
+// recordAddedItem notes which item in the basket returned by the API was the one just added: the item with the order's tag
+// which has not been recorded yet, or when the API does not echo the tag, the newest item which was not in the basket after
+// the order's previous add. Basket item IDs increase, so the newest is the highest.
+func (o *basketOrder) recordAddedItem(items []BasketItem) {
+	recorded := map[int]bool{}
+	for _, id := range o.itemIDs {
+		recorded[id] = true
+	}
+
+	added := -1
+	for i, item := range items {
+		if recorded[item.ID] {
+			continue
+		}
+		if o.tag != "" && item.ExternalReference == o.tag {
+			added = i
+			break
+		}
+		if !o.seen[item.ID] && (added < 0 || item.ID > items[added].ID) && item.ExternalReference == "" {
+			added = i
+		}
+	}
+	if added >= 0 {
+		o.itemIDs = append(o.itemIDs, items[added].ID)
+	}
+
+	o.seen = map[int]bool{}
+	for _, item := range items {
+		o.seen[item.ID] = true
+	}
+}
+
+// owns reports whether item was added by the order. Items are recognised by their tag, or by friendly name and
+// PO number when the API has not echoed the tag back.
+func (o *basketOrder) owns(item BasketItem) bool {
+	if item.ExternalReference != "" {
+		return item.ExternalReference == o.tag
+	}
+	for _, details := range o.added {
+		if strings.EqualFold(item.FriendlyName, details.FriendlyName) && item.PONumber == details.PONumber {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyBasketItems sorts the basket into the items added by order, leftovers from earlier runs of the provider, and
+// items added by anyone else. Without the basket lock file a leftover cannot be told apart from an item another
+// Terraform run is still ordering, so tagged items are only treated as leftovers when lockFileHeld is true, and even
+// then only once their tag is older than basketLeftoverAge, as the lock does not cover runs on other machines.
+func classifyBasketItems(items []BasketItem, order *basketOrder, lockFileHeld bool, now time.Time) (own, leftover, foreign []BasketItem) {
+	for _, item := range items {
+		switch {
+		case order.owns(item):
+			own = append(own, item)
+		case lockFileHeld && basketItemTagOlderThan(item.ExternalReference, now.Add(-basketLeftoverAge)):
+			leftover = append(leftover, item)
+		default:
+			foreign = append(foreign, item)
+		}
+	}
+	return own, leftover, foreign
+}
+
+// basketItemTagOlderThan reports whether ref is a provider tag from an order started before cutoff.
+// Tags without a time are never treated as old, their age is unknown
+func basketItemTagOlderThan(ref string, cutoff time.Time) bool {
+	rest, ok := strings.CutPrefix(ref, basketItemTagPrefix)
+	if !ok {
+		return false
+	}
+	started, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return false
+	}
+	seconds, err := strconv.ParseInt(started, 10, 64)
+	if err != nil {
+		return false
+	}
+	return time.Unix(seconds, 0).Before(cutoff)
+}
+
+// basketItemIDs lists the IDs of items for error messages
+func basketItemIDs(items []BasketItem) string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = strconv.Itoa(item.ID)
+	}
+	return strings.Join(ids, ", ")
 }
 
 // Checkout Struct for Checkout Post Request response
@@ -52,141 +221,151 @@ type BasketPayload struct {
 	Term         string `json:"term"`
 	DivisionID   *int   `json:"divisionId"`
 	BudgetCode   string `json:"budgetCode"`
-}
-
-func (c *Client) CreateBasket(friendlyName string, principalId string, poNumber string, budgetCode string) (*BasketDetails, error) {
-	return c.createBasketHelper(friendlyName, principalId, poNumber, budgetCode, 0)
+	// ExternalReference tags the item as added by the provider, see basketItemTagPrefix
+	ExternalReference string `json:"externalReference,omitempty"`
 }
 
 // CreateBasket creates a basket ready for checkout
-func (c *Client) createBasketHelper(friendlyName string, principalId string, poNumber string, budgetCode string, retryCount int) (*BasketDetails, error) {
-	url := fmt.Sprintf("%s/api/v2/contracts/%d/baskets", c.CommerceAPIURL, c.ContractID)
+func (c *Client) CreateBasket(ctx context.Context, details SubscriptionDetails) (*BasketDetails, error) {
+	tag, err := newBasketItemTag()
+	if err != nil {
+		return nil, err
+	}
+	return c.createBasketHelper(c.logContext(ctx), details, &basketOrder{tag: tag}, false)
+}
 
-	// Create a payload for the request
+// newBasketPayload builds the basket item for a subscription, using the default catalogue item for any field left empty
+func newBasketPayload(details SubscriptionDetails) BasketPayload {
 	payload := BasketPayload{
-		Quantity:     1,
-		FriendlyName: friendlyName,
-		ProductID:    "ENTITLEMENT",
-		SkuID:        "ENTITLEMENT",
-		PrincipalID:  principalId,
-		PriceID:      24492277,
-		PONumber:     poNumber,
-		BillingFreq:  "monthly",
-		Term:         "Perpetual",
+		Quantity:     details.Quantity,
+		FriendlyName: details.FriendlyName,
+		ProductID:    details.ProductID,
+		SkuID:        details.SkuID,
+		PrincipalID:  details.PrincipalID,
+		PriceID:      details.PriceID,
+		PONumber:     details.PONumber,
+		BillingFreq:  details.BillingFrequency,
+		Term:         details.Term,
 		DivisionID:   nil,
-		BudgetCode:   budgetCode,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %s", err)
+		BudgetCode:   details.BudgetCode,
 	}
 
-	// Submit the request with the payload
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %s", err)
+	// Leave the division unset unless one was given, so the API applies the contract default
+	if details.DivisionID != 0 {
+		divisionID := details.DivisionID
+		payload.DivisionID = &divisionID
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.CustomToken))
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Set("Content-Length", "0")
 
-	res, err := c.CustomHTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %s", err)
+	if payload.Quantity == 0 {
+		payload.Quantity = DefaultQuantity
 	}
-	defer res.Body.Close()
-
-	// Read the response body
-	bodyBytes, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
+	if payload.ProductID == "" {
+		payload.ProductID = DefaultProductID
+	}
+	if payload.SkuID == "" {
+		payload.SkuID = DefaultSkuID
+	}
+	if payload.PriceID == 0 {
+		payload.PriceID = DefaultPriceID
+	}
+	if payload.BillingFreq == "" {
+		payload.BillingFreq = DefaultBillingFrequency
+	}
+	if payload.Term == "" {
+		payload.Term = DefaultTerm
 	}
 
-	// Print the response body for debugging
-	fmt.Printf("Response Body: %s\n", string(bodyBytes))
+	return payload
+}
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected HTTP status code: %d, response body: %s", res.StatusCode, string(bodyBytes))
+// createBasketHelper adds an item to the contract basket for order, tagged so the provider can tell it apart from items added by anyone else.
+// Items left behind by an earlier failed run of the provider are removed when lockFileHeld and they are old enough that no other run
+// can still be using them, any other item is reported as an error and left alone.
+// Once the item has been added the basket is returned even if there is an error, and the item is recorded on order so the caller can roll it back.
+func (c *Client) createBasketHelper(ctx context.Context, details SubscriptionDetails, order *basketOrder, lockFileHeld bool) (*BasketDetails, error) {
+	url := fmt.Sprintf("%s/api/v2/contracts/%d/baskets", c.CommerceAPIURL, c.ContractID)
+
+	// Create a payload for the request
+	payload := newBasketPayload(details)
+	payload.ExternalReference = order.tag
+
+	// Submit the request with the payload, adding an item twice would order two subscriptions so it is not idempotent
+	bodyBytes, err := c.doRequest(ctx, "POST", url, payload, false)
+	if err != nil {
+		return nil, err
 	}
+	order.added = append(order.added, details)
 
 	// Unmarshal the response body into a struct
 	var basketdetails BasketDetails
 	err = json.Unmarshal(bodyBytes, &basketdetails)
 	if err != nil {
-		return nil, err
+		return &basketdetails, fmt.Errorf("failed to unmarshal basket, the item added for %s may need removing in the Bytes portal: %w", details.FriendlyName, err)
 	}
+	order.recordAddedItem(basketdetails.Items)
 
-	// If there's more than 2 items in the basket, then clear the basket and try submitting the request again
-	// This is due to the API creating a single basket until it's checked out or empty and prevents failures from creating multiple subscriptions
-	itemsLength := len(basketdetails.Items)
-	if itemsLength >= 2 {
-		for _, item := range basketdetails.Items {
-			deleteURL := fmt.Sprintf("%s/api/v1/CloudDashboard/DeleteBasketItem", c.CommerceAPIURL)
-			fmt.Printf("Deleting Item: %d", item.ID)
-
-			deletePayload := map[string]int{
-				"basketItemId": item.ID,
-			}
-
-			deleteData, err := json.Marshal(deletePayload)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal delete payload: %s", err)
-			}
-
-			deleteReq, err := http.NewRequest("POST", deleteURL, bytes.NewBuffer(deleteData))
-			if err != nil {
-				return nil, fmt.Errorf("failed to create delete request: %s", err)
-			}
+	// The API keeps a single basket until it's checked out or empty, so it can hold items other than the ones this order added.
+	ownItems, leftoverItems, foreignItems := classifyBasketItems(basketdetails.Items, order, lockFileHeld, time.Now())
 
-			deleteReq.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.CustomToken))
-			deleteReq.Header.Add("Content-Type", "application/json")
+	if len(foreignItems) > 0 {
+		return &basketdetails, fmt.Errorf("basket %d contains items which were not added by this order (ids: %s), "+
+			"check them out or remove them in the Bytes portal before ordering, or set basket_lock_file so Terraform can clear its own leftovers once they are over an hour old",
+			basketdetails.ID, basketItemIDs(foreignItems))
+	}
 
-			deleteRes, err := c.CustomHTTPClient.Do(deleteReq)
-			if err != nil || deleteRes.StatusCode != http.StatusOK {
-				return nil, fmt.Errorf("failed to delete basket item with id %d: %s", item.ID, err)
-			}
+	// Clear leftovers so they are not checked out with this order
+	for _, item := range leftoverItems {
+		if err := c.deleteBasketItem(ctx, item.ID); err != nil {
+			return &basketdetails, err
 		}
+	}
 
-		// Check if we're below the maximum retry count (e.g., 3 retries)
-		if retryCount < 3 {
-			return c.createBasketHelper(friendlyName, principalId, poNumber, budgetCode, retryCount+1)
-		} else {
-			return nil, fmt.Errorf("max retries reached while trying to create basket")
-		}
+	// Every subscription added must own exactly one item. Without the tag an item left over with the same friendly name
+	// and PO number looks like ours, and checking it out would order the subscription twice
+	if len(ownItems) < len(order.added) {
+		return &basketdetails, fmt.Errorf("basket %d did not contain the item added for %s", basketdetails.ID, details.FriendlyName)
+	}
+	if len(ownItems) > len(order.added) {
+		return &basketdetails, fmt.Errorf("basket %d contains more items matching this order than it added (ids: %s), "+
+			"remove the duplicates in the Bytes portal before ordering", basketdetails.ID, basketItemIDs(ownItems))
 	}
 
 	return &basketdetails, nil
 }
 
-// Checkout basket from previous CreateBasket Step
-func (c *Client) CheckoutBasket(basketdetails *BasketDetails) (*Checkout, error) {
-	url := fmt.Sprintf("%s/api/v2/contracts/%d/baskets/%d/checkout", c.CommerceAPIURL, c.ContractID, basketdetails.ID)
+// deleteBasketItem removes a single item from the contract basket
+func (c *Client) deleteBasketItem(ctx context.Context, basketItemID int) error {
+	deleteURL := fmt.Sprintf("%s/api/v1/CloudDashboard/DeleteBasketItem", c.CommerceAPIURL)
+	tflog.SubsystemInfo(ctx, logSubsystemCommerce, "Deleting basket item", map[string]interface{}{"basket_item_id": basketItemID})
 
-	// Submit the request
-	req, err := http.NewRequest("POST", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %s", err)
+	deletePayload := map[string]int{
+		"basketItemId": basketItemID,
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.CustomToken))
 
-	res, err := c.CustomHTTPClient.Do(req)
+	_, err := c.doRequest(ctx, "POST", deleteURL, deletePayload, true)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %s", err)
+		return fmt.Errorf("failed to delete basket item with id %d: %w", basketItemID, err)
 	}
-	defer res.Body.Close()
+	return nil
+}
 
-	// Read the response body
-	bodyBytes, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
+// newBasketItemTag returns a tag which is unique to one order and marks its basket items as added by the provider
+func newBasketItemTag() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate basket item tag: %s", err)
 	}
+	return fmt.Sprintf("%s%d-%s", basketItemTagPrefix, time.Now().Unix(), hex.EncodeToString(b)), nil
+}
 
-	// Print the response body for debugging
-	fmt.Printf("Response Body: %s\n", string(bodyBytes))
+// Checkout basket from previous CreateBasket Step
+func (c *Client) CheckoutBasket(ctx context.Context, basketdetails *BasketDetails) (*Checkout, error) {
+	url := fmt.Sprintf("%s/api/v2/contracts/%d/baskets/%d/checkout", c.CommerceAPIURL, c.ContractID, basketdetails.ID)
 
-	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusAccepted {
-		bodyBytes, _ := io.ReadAll(res.Body)
-		return nil, fmt.Errorf("unexpected HTTP status code: %d, response body: %s", res.StatusCode, string(bodyBytes))
+	// Submit the request, a repeated checkout could raise a second order so it is not idempotent
+	bodyBytes, err := c.doRequest(ctx, "POST", url, nil, false)
+	if err != nil {
+		return nil, err
 	}
 
 	// Unmarshal the response body into a struct
@@ -200,84 +379,303 @@ func (c *Client) CheckoutBasket(basketdetails *BasketDetails) (*Checkout, error)
 }
 
 // CreateSubscription creates a subscription by first creating a basket and then proceeding to checkout
-func (c *Client) CreateSubscription(details SubscriptionDetails) (*OrderDetails, error) {
-	// First, create a basket
-	basketInfo, err := c.CreateBasket(details.FriendlyName, details.PrincipalID, details.PONumber, details.BudgetCode)
+func (c *Client) CreateSubscription(ctx context.Context, details SubscriptionDetails) (*OrderDetails, error) {
+	checkoutInfo, err := c.OrderSubscription(ctx, details)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create basket: %s", err)
+		return nil, err
 	}
 
-	// Then, checkout the basket using the returned ID
-	checkoutInfo, err := c.CheckoutBasket(basketInfo)
+	return c.WaitForSubscription(ctx, fmt.Sprintf("%d", checkoutInfo.ID))
+}
+
+// OrderSubscription places the order for a subscription by creating a basket and checking it out.
+// Once it returns successfully the subscription has been bought, even though it is not provisioned yet.
+func (c *Client) OrderSubscription(ctx context.Context, details SubscriptionDetails) (*Checkout, error) {
+	return c.OrderSubscriptions(ctx, []SubscriptionDetails{details})
+}
+
+// OrderSubscriptions places a single order for several subscriptions, adding them all to the basket and checking out once.
+// Each subscription must have a different friendly name, which is how the order items are matched back to them.
+func (c *Client) OrderSubscriptions(ctx context.Context, subscriptions []SubscriptionDetails) (*Checkout, error) {
+	ctx = c.logContext(ctx)
+
+	if len(subscriptions) == 0 {
+		return nil, fmt.Errorf("no subscriptions to order")
+	}
+
+	// Check the friendly names and divisions before ordering anything
+	friendlyNames := map[string]bool{}
+	divisions := map[int]bool{}
+	for _, details := range subscriptions {
+		if friendlyNames[details.FriendlyName] {
+			return nil, fmt.Errorf("friendly name %q is used by more than one subscription in the order", details.FriendlyName)
+		}
+		friendlyNames[details.FriendlyName] = true
+
+		if details.DivisionID != 0 && !divisions[details.DivisionID] {
+			if err := c.ValidateDivision(ctx, details.DivisionID); err != nil {
+				return nil, err
+			}
+			divisions[details.DivisionID] = true
+		}
+	}
+
+	// Hold the contract basket from adding the item through to checkout, so parallel orders cannot clear or check out each other's items
+	unlock, err := c.lockBasket(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to checkout basket: %s", err)
+		return nil, err
 	}
+	defer unlock()
 
-	// Wait for a maximum of 20 minutes for subscriptionId to be not null.
-	// Check every 30 seconds
-	maxRetries := 40
-	retryInterval := 30 * time.Second
-	var subscriptionInfo *OrderDetails
-	for i := 0; i < maxRetries; i++ {
-		// Lastly, check the status of the order using the Checkout ID
-		subscriptionInfo, err = c.GetOrderDetails(fmt.Sprintf("%d", checkoutInfo.ID))
+	// First, add every subscription to the basket under one tag
+	tag, err := newBasketItemTag()
+	if err != nil {
+		return nil, err
+	}
+	order := &basketOrder{tag: tag}
+	var basketInfo *BasketDetails
+	for _, details := range subscriptions {
+		basket, err := c.createBasketHelper(ctx, details, order, c.BasketLockFile != "")
+		if basket != nil {
+			basketInfo = basket
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch order details: %s", err)
+			return nil, c.rollbackBasket(ctx, basketInfo, order, fmt.Errorf("failed to create basket: %w", err))
 		}
+	}
 
-		// Check if subscriptionId is not null for the first item
-		if subscriptionInfo.Items[0].SubscriptionID != "" {
-			fmt.Printf("SubscriptionId: %s\n", subscriptionInfo.Items[0].SubscriptionID)
-			break
+	// Then, checkout the basket using the returned ID
+	checkoutInfo, err := c.CheckoutBasket(ctx, basketInfo)
+	if err != nil {
+		return nil, c.rollbackBasket(ctx, basketInfo, order, fmt.Errorf("failed to checkout basket: %w", err))
+	}
+
+	return checkoutInfo, nil
+}
+
+// basketRollbackTimeout bounds the cleanup after a failed order, which still runs when Terraform has been interrupted
+const basketRollbackTimeout = 2 * time.Minute
+
+// rollbackBasket removes the items an order added to the basket after it failed, so they are not checked out by the next order.
+// It returns cause joined with any error from the cleanup. Items are removed by the IDs recorded as they were added, never by
+// matching the basket contents, so an item which only looks like one of the order's is left alone. An item added by a request
+// whose response was lost is not known here, and is removed as a leftover by the next order holding the basket lock file.
+func (c *Client) rollbackBasket(ctx context.Context, basket *BasketDetails, order *basketOrder, cause error) error {
+	if basket == nil || len(order.itemIDs) == 0 {
+		return cause
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), basketRollbackTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, id := range order.itemIDs {
+		// An item which is no longer there was checked out or removed already
+		if err := c.deleteBasketItem(ctx, id); err != nil && !IsNotFound(err) {
+			errs = append(errs, err)
 		}
-		fmt.Printf("SubscriptionId is blank, waiting...")
-		// Wait for the retry interval before the next check
-		time.Sleep(retryInterval)
 	}
 
-	if subscriptionInfo.Items[0].SubscriptionID == "" {
-		return nil, fmt.Errorf("subscriptionId did not update after waiting for the maximum allowed time")
+	if len(errs) > 0 {
+		return errors.Join(cause, fmt.Errorf("failed to roll back basket %d, remove the items tagged %q in the Bytes portal: %w", basket.ID, order.tag, errors.Join(errs...)))
+	}
+	tflog.SubsystemInfo(ctx, logSubsystemCommerce, "Rolled back basket after failed order", map[string]interface{}{"basket_id": basket.ID})
+	return errors.Join(cause, fmt.Errorf("basket %d rolled back", basket.ID))
+}
+
+// WaitForSubscription polls an order until the subscriptions for all of its items have been provisioned
+func (c *Client) WaitForSubscription(ctx context.Context, orderID string) (*OrderDetails, error) {
+	ctx = c.logContext(ctx)
+
+	// Wait for subscriptionId to be not null until the context deadline, which is set by the resource timeout.
+	// Check every PollInterval
+	for {
+		// Check the status of the order using the Checkout ID
+		subscriptionInfo, err := c.GetOrderDetails(ctx, orderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch order details: %w", err)
+		}
+
+		// Check if subscriptionId is not null for every item
+		if subscriptionInfo.Provisioned() {
+			tflog.SubsystemInfo(ctx, logSubsystemCommerce, "Subscriptions provisioned", map[string]interface{}{
+				"order_id": orderID,
+				"items":    len(subscriptionInfo.Items),
+			})
+			return subscriptionInfo, nil
+		}
+		tflog.SubsystemDebug(ctx, logSubsystemCommerce, "SubscriptionId is blank, waiting", map[string]interface{}{
+			"order_id": orderID,
+			"wait":     c.PollInterval.String(),
+		})
+		// Wait for the poll interval before the next check, stopping if Terraform is interrupted or the timeout is reached
+		if err := sleepContext(ctx, c.PollInterval); err != nil {
+			return nil, fmt.Errorf("subscriptionId of order %s did not update before the timeout: %w", orderID, err)
+		}
 	}
+}
+
+// SubscriptionUpdatePayload Struct for the Update Subscription Post Request payload. The billing details are always sent,
+// so an empty PO number or budget code is cleared on the subscription, while the name, admin and division are left as they are when not given
+type SubscriptionUpdatePayload struct {
+	FriendlyName string `json:"friendlyName,omitempty"`
+	PONumber     string `json:"poNumber"`
+	PrincipalID  string `json:"principalId,omitempty"`
+	BudgetCode   string `json:"budgetCode"`
+	DivisionID   *int   `json:"divisionId,omitempty"`
+}
 
-	return subscriptionInfo, nil
+// NewSubscriptionUpdatePayload builds the update for the name, billing details and admin of a subscription
+func NewSubscriptionUpdatePayload(details SubscriptionDetails) SubscriptionUpdatePayload {
+	payload := SubscriptionUpdatePayload{
+		FriendlyName: details.FriendlyName,
+		PONumber:     details.PONumber,
+		PrincipalID:  details.PrincipalID,
+		BudgetCode:   details.BudgetCode,
+	}
+	// Leave the division as it is unless one is given
+	if details.DivisionID != 0 {
+		divisionID := details.DivisionID
+		payload.DivisionID = &divisionID
+	}
+	return payload
 }
 
-func (c *Client) UpdateSubscription(subscriptionID string, details SubscriptionDetails) (*OrderDetails, error) {
+// UpdateSubscription changes the name, billing details and admin of a subscription. subscriptionID is the Azure subscription ID,
+// not the ID of the order which created it
+func (c *Client) UpdateSubscription(ctx context.Context, subscriptionID string, update SubscriptionUpdatePayload) (*Subscription, error) {
 	url := fmt.Sprintf("%s/api/v2/subscriptions/%s", c.CommerceAPIURL, subscriptionID)
 
-	data, err := json.Marshal(details)
+	// Change HTTP method from PATCH to POST, repeating the update sets the same values so it is safe to retry
+	bodyBytes, err := c.doRequest(ctx, "POST", url, update, true)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %s", err)
+		return nil, err
 	}
 
-	// Change HTTP method from PATCH to POST
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
+	var subscription Subscription
+	err = json.Unmarshal(bodyBytes, &subscription)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %s", err)
+		return nil, fmt.Errorf("failed to unmarshal response body: %s", err)
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.CustomToken))
-	req.Header.Add("Content-Type", "application/json")
 
-	res, err := c.CustomHTTPClient.Do(req)
+	return &subscription, nil
+}
+
+// GetSubscription fetches the details of an Azure subscription held under the contract
+func (c *Client) GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	url := fmt.Sprintf("%s/api/v2/subscriptions/%s", c.CommerceAPIURL, subscriptionID)
+	bodyBytes, err := c.doRequest(ctx, "GET", url, nil, true)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %s", err)
+		return nil, err
 	}
-	defer res.Body.Close()
 
-	bodyBytes, err := io.ReadAll(res.Body)
+	var subscription Subscription
+	err = json.Unmarshal(bodyBytes, &subscription)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %s", err)
+		return nil, fmt.Errorf("failed to unmarshal response body: %s", err)
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected HTTP status code: %d, response body: %s", res.StatusCode, string(bodyBytes))
+	return &subscription, nil
+}
+
+// CancelSubscription asks Bytes to cancel a subscription, stopping its billing. subscriptionID is the Azure subscription ID
+func (c *Client) CancelSubscription(ctx context.Context, subscriptionID string) error {
+	url := fmt.Sprintf("%s/api/v2/subscriptions/%s/cancel", c.CommerceAPIURL, subscriptionID)
+	tflog.SubsystemInfo(c.logContext(ctx), logSubsystemCommerce, "Cancelling subscription", map[string]interface{}{"subscription_id": subscriptionID})
+
+	// Cancelling a subscription twice leaves it cancelled, so it is safe to repeat
+	_, err := c.doRequest(ctx, "POST", url, nil, true)
+	return err
+}
+
+// WaitForCancellation polls a subscription until Bytes acknowledges its cancellation, or it no longer exists
+func (c *Client) WaitForCancellation(ctx context.Context, subscriptionID string) error {
+	ctx = c.logContext(ctx)
+
+	for {
+		subscription, err := c.GetSubscription(ctx, subscriptionID)
+		if IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch subscription: %w", err)
+		}
+
+		if subscription.Cancelled() {
+			tflog.SubsystemInfo(ctx, logSubsystemCommerce, "Subscription cancellation acknowledged", map[string]interface{}{
+				"subscription_id": subscriptionID,
+				"status":          subscription.Status,
+			})
+			return nil
+		}
+		tflog.SubsystemDebug(ctx, logSubsystemCommerce, "Subscription not cancelled yet, waiting", map[string]interface{}{
+			"subscription_id": subscriptionID,
+			"status":          subscription.Status,
+			"wait":            c.PollInterval.String(),
+		})
+		// Wait for the poll interval before the next check, stopping if Terraform is interrupted or the timeout is reached
+		if err := sleepContext(ctx, c.PollInterval); err != nil {
+			return fmt.Errorf("cancellation of subscription %s was not acknowledged before the timeout: %w", subscriptionID, err)
+		}
+	}
+}
+
+// ListSubscriptionsOptions Filters for the subscription list, any field left empty is not filtered on
+type ListSubscriptionsOptions struct {
+	Status     string
+	PONumber   string
+	BudgetCode string
+	DivisionID int
+}
+
+// query returns the filters as query parameters for the subscription list
+func (o ListSubscriptionsOptions) query() url.Values {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"status":     o.Status,
+		"poNumber":   o.PONumber,
+		"budgetCode": o.BudgetCode,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
 	}
+	if o.DivisionID != 0 {
+		query.Set("divisionId", strconv.Itoa(o.DivisionID))
+	}
+	return query
+}
+
+// ListSubscriptions fetches every subscription held under the contract which matches opts, following the pages of the subscription list
+func (c *Client) ListSubscriptions(ctx context.Context, opts ListSubscriptionsOptions) ([]Subscription, error) {
+	return listPages[Subscription](ctx, c, fmt.Sprintf("/api/v2/contracts/%d/subscriptions", c.ContractID), opts.query())
+}
 
-	var order OrderDetails
-	err = json.Unmarshal(bodyBytes, &order)
+// FindSubscriptionByFriendlyName returns the subscription held under the contract with the given friendly name.
+// Friendly names are compared case-insensitively, as the portal does, and must match exactly one subscription.
+func (c *Client) FindSubscriptionByFriendlyName(ctx context.Context, friendlyName string) (*Subscription, error) {
+	subscriptions, err := c.ListSubscriptions(ctx, ListSubscriptionsOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response body: %s", err)
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
 	}
 
-	return &order, nil
+	var matches []Subscription
+	for _, subscription := range subscriptions {
+		if strings.EqualFold(subscription.FriendlyName, friendlyName) {
+			matches = append(matches, subscription)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no subscription named %q was found on the contract", friendlyName)
+	case 1:
+		return &matches[0], nil
+	}
+
+	ids := make([]string, len(matches))
+	for i, match := range matches {
+		ids[i] = match.SubscriptionID
+	}
+	return nil, fmt.Errorf("%d subscriptions are named %q (ids: %s), look it up by subscription ID instead", len(matches), friendlyName, strings.Join(ids, ", "))
 }
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClassifyBasketItems(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tagAt := func(started time.Time, suffix string) string {
+		return fmt.Sprintf("%s%d-%s", basketItemTagPrefix, started.Unix(), suffix)
+	}
+	order := &basketOrder{
+		tag:   tagAt(now, "mine"),
+		added: []SubscriptionDetails{{FriendlyName: "examplesub", PONumber: "PO-1"}},
+	}
+	items := map[string]BasketItem{
+		"tagged":           {ID: 1, ExternalReference: tagAt(now, "mine")},
+		"untagged match":   {ID: 2, FriendlyName: "ExampleSub", PONumber: "PO-1"},
+		"untagged other":   {ID: 3, FriendlyName: "examplesub", PONumber: "PO-2"},
+		"old run":          {ID: 4, ExternalReference: tagAt(now.Add(-2*basketLeftoverAge), "theirs"), FriendlyName: "examplesub", PONumber: "PO-1"},
+		"another client":   {ID: 5, ExternalReference: "portal"},
+		"tag not matched":  {ID: 6, ExternalReference: tagAt(now, "mine-too"), FriendlyName: "examplesub", PONumber: "PO-1"},
+		"recent run":       {ID: 7, ExternalReference: tagAt(now.Add(-time.Minute), "theirs")},
+		"tag without time": {ID: 8, ExternalReference: basketItemTagPrefix + "theirs"},
+	}
+
+	tests := []struct {
+		name         string
+		item         string
+		lockFileHeld bool
+		want         string
+	}{
+		{name: "own tag", item: "tagged", want: "own"},
+		{name: "tag not echoed, friendly name and PO match", item: "untagged match", want: "own"},
+		{name: "tag not echoed, PO differs", item: "untagged other", want: "foreign"},
+		{name: "old tag with lock file", item: "old run", lockFileHeld: true, want: "leftover"},
+		{name: "old tag without lock file", item: "old run", want: "foreign"},
+		{name: "recent tag with lock file, possibly a run on another machine", item: "recent run", lockFileHeld: true, want: "foreign"},
+		{name: "tag of unknown age with lock file", item: "tag without time", lockFileHeld: true, want: "foreign"},
+		{name: "other tag is not matched on friendly name", item: "tag not matched", want: "foreign"},
+		{name: "not added by the provider", item: "another client", lockFileHeld: true, want: "foreign"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			own, leftover, foreign := classifyBasketItems([]BasketItem{items[tt.item]}, order, tt.lockFileHeld, now)
+
+			got := map[string]int{"own": len(own), "leftover": len(leftover), "foreign": len(foreign)}
+			for class, n := range got {
+				if want := map[bool]int{true: 1}[class == tt.want]; n != want {
+					t.Errorf("%s items = %d, want %d", class, n, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSubscriptionUpdatePayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		details SubscriptionDetails
+		want    string
+	}{
+		{
+			name:    "name, admin and division left as they are",
+			details: SubscriptionDetails{PONumber: "PO-1", BudgetCode: "12345"},
+			want:    `{"poNumber":"PO-1","budgetCode":"12345"}`,
+		},
+		{
+			name:    "every field given",
+			details: SubscriptionDetails{FriendlyName: "examplesub", PONumber: "PO-1", PrincipalID: "admin@example.com", BudgetCode: "12345", DivisionID: 3},
+			want:    `{"friendlyName":"examplesub","poNumber":"PO-1","principalId":"admin@example.com","budgetCode":"12345","divisionId":3}`,
+		},
+		{
+			name:    "billing details cleared",
+			details: SubscriptionDetails{},
+			want:    `{"poNumber":"","budgetCode":""}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(NewSubscriptionUpdatePayload(tt.details))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("payload = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderSubscriptionsRollsBackAddedItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		echoTag bool
+		basket  []BasketItem
+	}{
+		{
+			name:   "untagged leftover with the same details",
+			basket: []BasketItem{{ID: 10, FriendlyName: "examplesub", PONumber: "PO-1"}},
+		},
+		{
+			name:    "item of another run without the lock file",
+			echoTag: true,
+			basket:  []BasketItem{{ID: 10, FriendlyName: "othersub", PONumber: "PO-1", ExternalReference: basketItemTagPrefix + "theirs"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			basket := append([]BasketItem(nil), tt.basket...)
+			var deleted []int
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				switch r.URL.Path {
+				case "/api/v2/contracts/1/baskets":
+					var payload BasketPayload
+					json.NewDecoder(r.Body).Decode(&payload)
+					item := BasketItem{ID: 11, FriendlyName: payload.FriendlyName, PONumber: payload.PONumber}
+					if tt.echoTag {
+						item.ExternalReference = payload.ExternalReference
+					}
+					basket = append(basket, item)
+					json.NewEncoder(w).Encode(BasketDetails{ID: 7, Items: basket})
+				case "/api/v1/CloudDashboard/DeleteBasketItem":
+					var payload map[string]int
+					json.NewDecoder(r.Body).Decode(&payload)
+					deleted = append(deleted, payload["basketItemId"])
+					for i, item := range basket {
+						if item.ID == payload["basketItemId"] {
+							basket = append(basket[:i], basket[i+1:]...)
+							break
+						}
+					}
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+
+			_, err := c.OrderSubscription(context.Background(), SubscriptionDetails{FriendlyName: "examplesub", PONumber: "PO-1"})
+			if err == nil {
+				t.Fatal("expected the order to fail")
+			}
+			if len(deleted) != 1 || deleted[0] != 11 {
+				t.Errorf("deleted = %v, want only the added item 11", deleted)
+			}
+			if len(basket) != len(tt.basket) {
+				t.Errorf("basket = %+v, want it back to the items it held before", basket)
+			}
+		})
+	}
+}
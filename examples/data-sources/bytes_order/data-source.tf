@@ -1,4 +1,9 @@
 # Query an existing order
 data "bytes_order" "example" {
   order_id = "12345"
-}
\ No newline at end of file
+}
+
+# Every subscription in the order
+output "subscription_ids" {
+  value = data.bytes_order.example.items[*].subscription_id
+}
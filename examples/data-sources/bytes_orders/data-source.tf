@@ -0,0 +1,5 @@
+# List the development subscriptions ordered this year
+data "bytesnew_orders" "example" {
+  created_after       = "2023-01-01"
+  friendly_name_regex = "-dev$"
+}
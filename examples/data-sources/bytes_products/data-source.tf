@@ -0,0 +1,14 @@
+# Find the annual price of the Azure plan entitlement
+data "bytesnew_products" "example" {
+  product_id        = "ENTITLEMENT"
+  billing_frequency = "annual"
+}
+
+# Order a subscription at that price
+resource "bytesnew_subscription" "example" {
+  friendly_name     = "examplesub"
+  po_number         = "13102023-example"
+  budget_code       = "12345"
+  billing_frequency = "annual"
+  price_id          = data.bytesnew_products.example.prices[0].price_id
+}
@@ -0,0 +1,9 @@
+# Look up the Bytes order and billing details of an Azure subscription
+data "bytesnew_subscription" "example" {
+  subscription_id = "00000000-0000-0000-0000-000000000000"
+}
+
+# Or look it up by its friendly name
+data "bytesnew_subscription" "by_name" {
+  friendly_name = "examplesub"
+}
@@ -0,0 +1,10 @@
+# List the active subscriptions billed to a budget code
+data "bytesnew_subscriptions" "example" {
+  status      = "Active"
+  budget_code = "12345"
+}
+
+# Subscription IDs keyed by friendly name, e.g. for for_each
+output "subscription_ids" {
+  value = { for s in data.bytesnew_subscriptions.example.subscriptions : s.friendly_name => s.subscription_id }
+}
@@ -0,0 +1,5 @@
+# Import using the Bytes order ID
+terraform import bytesnew_subscription.example 12345
+
+# Import using the Azure subscription ID
+terraform import bytesnew_subscription.example 00000000-0000-0000-0000-000000000000
@@ -4,4 +4,12 @@ resource "bytes_subscription" "example" {
   po_number = "13102023-example"
   default_admin = "username@domain.uk.com"
   budget_code = "12345"
+
+  # Cancel the subscription on destroy, as long as it holds no Azure resources
+  deletion_policy = "cancel"
+
+  # Provisioning can take longer than the default 20 minutes
+  timeouts {
+    create = "45m"
+  }
 }
\ No newline at end of file
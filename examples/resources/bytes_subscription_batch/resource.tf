@@ -0,0 +1,16 @@
+# Order several subscriptions with a single checkout
+resource "bytesnew_subscription_batch" "example" {
+  subscription {
+    friendly_name = "examplesub-dev"
+    po_number = "13102023-example"
+    default_admin = "username@domain.uk.com"
+    budget_code = "12345"
+  }
+
+  subscription {
+    friendly_name = "examplesub-prod"
+    po_number = "13102023-example"
+    default_admin = "username@domain.uk.com"
+    budget_code = "12345"
+  }
+}
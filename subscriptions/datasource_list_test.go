@@ -0,0 +1,100 @@
+package subscriptions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"terraform-provider-bytesnew/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// ignoredFiltersHandler fakes list endpoints which ignore every filter, returning the whole list as one page
+func ignoredFiltersHandler(t *testing.T, lists map[string]interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		items, ok := lists[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	}
+}
+
+func TestListDataSourcesFilterResults(t *testing.T) {
+	division := 3
+	c := newTestClient(t, ignoredFiltersHandler(t, map[string]interface{}{
+		"/api/v2/contracts/1/orders": []client.OrderDetails{
+			{ID: 1, Status: "Complete", CreateDate: "2023-10-13T09:00:00Z", Items: []client.OrderItem{{PONumber: "PO-1"}}},
+			{ID: 2, Status: "complete", CreateDate: "2023-10-14T09:00:00Z", Items: []client.OrderItem{{PONumber: "PO-1"}}},
+			{ID: 3, Status: "Complete", CreateDate: "2023-10-13T09:00:00Z", Items: []client.OrderItem{{PONumber: "PO-2"}}},
+			{ID: 4, Status: "Pending", CreateDate: "2023-10-13T09:00:00Z", Items: []client.OrderItem{{PONumber: "PO-1"}}},
+		},
+		"/api/v2/contracts/1/subscriptions": []client.Subscription{
+			{SubscriptionID: "sub-1", Status: "Active", PONumber: "PO-1", BudgetCode: "12345", DivisionID: &division},
+			{SubscriptionID: "sub-2", Status: "Active", PONumber: "PO-1", BudgetCode: "12345"},
+			{SubscriptionID: "sub-3", Status: "Active", PONumber: "PO-1", BudgetCode: "54321", DivisionID: &division},
+			{SubscriptionID: "sub-4", Status: "Cancelled", PONumber: "PO-1", BudgetCode: "12345", DivisionID: &division},
+		},
+		"/api/v2/contracts/1/catalogue/products": []client.CatalogueProduct{
+			{ProductID: "ENTITLEMENT", Skus: []client.CatalogueSku{{SkuID: "DZH318Z0BPS6", Prices: []client.CataloguePrice{
+				{PriceID: 1, BillingFrequency: "monthly", Term: "Perpetual"},
+				{PriceID: 2, BillingFrequency: "annual", Term: "Perpetual"},
+			}}}},
+			{ProductID: "OTHER", Skus: []client.CatalogueSku{{SkuID: "DZH318Z0BPS6", Prices: []client.CataloguePrice{
+				{PriceID: 3, BillingFrequency: "monthly", Term: "Perpetual"},
+			}}}},
+		},
+	}))
+
+	tests := []struct {
+		name     string
+		resource *schema.Resource
+		config   map[string]interface{}
+		list     string
+		key      string
+		want     interface{}
+	}{
+		{
+			name:     "orders",
+			resource: datasourceOrders(),
+			config:   map[string]interface{}{"created_before": "2023-10-13", "status": "complete", "po_number": "PO-1"},
+			list:     "orders",
+			key:      "id",
+			want:     1,
+		},
+		{
+			name:     "subscriptions",
+			resource: datasourceSubscriptions(),
+			config:   map[string]interface{}{"status": "active", "po_number": "PO-1", "budget_code": "12345", "division_id": 3},
+			list:     "subscriptions",
+			key:      "subscription_id",
+			want:     "sub-1",
+		},
+		{
+			name:     "products",
+			resource: datasourceProducts(),
+			config:   map[string]interface{}{"product_id": "entitlement", "sku_id": "DZH318Z0BPS6", "billing_frequency": "Monthly"},
+			list:     "prices",
+			key:      "price_id",
+			want:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, tt.resource.Schema, tt.config)
+			requireNoErrors(t, tt.resource.ReadContext(context.Background(), d, c))
+
+			results := d.Get(tt.list).([]interface{})
+			if len(results) != 1 {
+				t.Fatalf("%s = %v, want one result", tt.list, results)
+			}
+			if got := results[0].(map[string]interface{})[tt.key]; got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package subscriptions
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+
+	"terraform-provider-bytesnew/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// This datasource is used to list the orders placed on the contract
+func datasourceOrders() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOrdersRead,
+
+		// Initialise all vars for datasource
+		Schema: map[string]*schema.Schema{
+			"created_after": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDate,
+				Description:  "Only return orders created on or after this date, e.g. `2023-10-13` or `2023-10-13T09:00:00Z`",
+			},
+			"created_before": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDate,
+				Description:  "Only return orders created on or before this date, e.g. `2023-10-13` or `2023-10-13T09:00:00Z`",
+			},
+			"po_number": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return orders with an item using this purchase order number",
+			},
+			"friendly_name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Only return orders with an item whose friendly name matches this regular expression",
+			},
+			"status": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return orders with this status",
+			},
+			"orders": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The orders matching every filter",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Bytes order ID",
+						},
+						"contract_name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Bytes contract name used for the order",
+						},
+						"create_date": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The date the order was created",
+						},
+						"status": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The status of the order",
+						},
+						"items": orderItemsSchema(),
+					},
+				},
+			},
+		},
+		Description: "List the Bytes orders placed on the contract.\n\n" +
+			"Use this data source to enumerate what has been purchased, optionally filtered by date, PO number, friendly name or status.",
+	}
+}
+
+// orderItemsSchema is the computed list of the items within an order
+func orderItemsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "Every item within the order",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"subscription_id": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "Subscription ID returned by Azure, empty until it has been provisioned",
+				},
+				"friendly_name": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "Friendly name of the subscription",
+				},
+				"po_number": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "Purchase order number for the subscription",
+				},
+				"principal_id": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The default admin assigned to the subscription",
+				},
+				"cloud_subscription_id": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "Bytes ID of the cloud subscription",
+				},
+				"division_id": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "The division ID used for subscription billing",
+				},
+			},
+		},
+	}
+}
+
+// datasourceOrdersRead is used to read the datasource and set the schema
+func datasourceOrdersRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*client.Client)
+
+	opts := client.ListOrdersOptions{
+		CreatedFrom: d.Get("created_after").(string),
+		CreatedTo:   d.Get("created_before").(string),
+		PONumber:    d.Get("po_number").(string),
+		Status:      d.Get("status").(string),
+	}
+	orders, err := c.ListOrders(ctx, opts)
+	if err != nil {
+		return apiErrorDiagnostics("Unable to list orders", err)
+	}
+
+	// The API cannot match friendly names by pattern, so that filter is only applied here. The pattern has already been validated
+	var friendlyNameRegex *regexp.Regexp
+	if pattern := d.Get("friendly_name_regex").(string); pattern != "" {
+		friendlyNameRegex = regexp.MustCompile(pattern)
+	}
+
+	result := make([]interface{}, 0, len(orders))
+	for _, order := range orders {
+		if !createdBetween(order.CreateDate, opts.CreatedFrom, opts.CreatedTo) || !matchesFilter(opts.Status, order.Status) {
+			continue
+		}
+		if opts.PONumber != "" && !orderHasPONumber(order, opts.PONumber) {
+			continue
+		}
+		if friendlyNameRegex != nil && !orderHasFriendlyName(order, friendlyNameRegex) {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"id":            order.ID,
+			"contract_name": order.ContractName,
+			"create_date":   order.CreateDate,
+			"status":        order.Status,
+			"items":         flattenOrderItems(order.Items),
+		})
+	}
+
+	if err := d.Set("orders", result); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
+
+	return nil
+}
+
+// orderHasFriendlyName reports whether any item within the order has a friendly name matching pattern
+func orderHasFriendlyName(order client.OrderDetails, pattern *regexp.Regexp) bool {
+	for _, item := range order.Items {
+		if pattern.MatchString(item.FriendlyName) {
+			return true
+		}
+	}
+	return false
+}
+
+// orderHasPONumber reports whether any item within the order uses poNumber
+func orderHasPONumber(order client.OrderDetails, poNumber string) bool {
+	for _, item := range order.Items {
+		if matchesFilter(poNumber, item.PONumber) {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenOrderItems converts order items to the list set on the items attribute
+func flattenOrderItems(items []client.OrderItem) []interface{} {
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		flattened := map[string]interface{}{
+			"subscription_id": item.SubscriptionID,
+			"friendly_name":   item.FriendlyName,
+			"po_number":       item.PONumber,
+			"principal_id":    item.PrincipalID,
+		}
+		if item.CloudSubscriptionID != nil {
+			flattened["cloud_subscription_id"] = *item.CloudSubscriptionID
+		}
+		if item.DivisionID != nil {
+			flattened["division_id"] = *item.DivisionID
+		}
+		result[i] = flattened
+	}
+	return result
+}
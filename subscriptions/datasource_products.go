@@ -0,0 +1,168 @@
+package subscriptions
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+
+	"terraform-provider-bytesnew/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// This datasource is used to look up prices in the Bytes product catalogue
+func datasourceProducts() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceProductsRead,
+
+		// Initialise all vars for datasource
+		Schema: map[string]*schema.Schema{
+			"product_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return prices for this catalogue product, e.g. `ENTITLEMENT`",
+			},
+			"sku_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return prices for this SKU",
+			},
+			"billing_frequency": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return prices with this billing frequency, e.g. `monthly` or `annual`",
+			},
+			"term": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return prices with this commitment term, e.g. `Perpetual`",
+			},
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Only return prices whose product or SKU name matches this regular expression",
+			},
+			"prices": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Every price matching the filters, one per product, SKU, billing frequency and term",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"product_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The catalogue product, used as `product_id` when ordering",
+						},
+						"product_name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the product",
+						},
+						"sku_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The SKU of the product, used as `sku_id` when ordering",
+						},
+						"sku_name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the SKU",
+						},
+						"price_id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The ID of the price, used as `price_id` when ordering",
+						},
+						"billing_frequency": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "How often the price is billed",
+						},
+						"term": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The commitment term of the price",
+						},
+						"unit_price": {
+							Type:        schema.TypeFloat,
+							Computed:    true,
+							Description: "The price of a single unit",
+						},
+						"currency": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The currency of the unit price",
+						},
+					},
+				},
+			},
+		},
+		Description: "Look up prices in the Bytes product catalogue.\n\n" +
+			"Use this data source to find the `product_id`, `sku_id` and `price_id` to order a subscription with, rather than hardcoding them.",
+	}
+}
+
+// datasourceProductsRead is used to read the datasource and set the schema
+func datasourceProductsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*client.Client)
+
+	opts := client.ListCatalogueProductsOptions{
+		ProductID:        d.Get("product_id").(string),
+		SkuID:            d.Get("sku_id").(string),
+		BillingFrequency: d.Get("billing_frequency").(string),
+		Term:             d.Get("term").(string),
+	}
+	products, err := c.ListCatalogueProducts(ctx, opts)
+	if err != nil {
+		return apiErrorDiagnostics("Unable to list catalogue products", err)
+	}
+
+	// The pattern has already been validated
+	var nameRegex *regexp.Regexp
+	if pattern := d.Get("name_regex").(string); pattern != "" {
+		nameRegex = regexp.MustCompile(pattern)
+	}
+
+	// Products are returned with all of their SKUs and prices, so the filters are applied to each level here
+	prices := []interface{}{}
+	for _, product := range products {
+		if !matchesFilter(opts.ProductID, product.ProductID) {
+			continue
+		}
+		for _, sku := range product.Skus {
+			if !matchesFilter(opts.SkuID, sku.SkuID) {
+				continue
+			}
+			if nameRegex != nil && !nameRegex.MatchString(product.Name) && !nameRegex.MatchString(sku.Name) {
+				continue
+			}
+			for _, price := range sku.Prices {
+				if !matchesFilter(opts.BillingFrequency, price.BillingFrequency) || !matchesFilter(opts.Term, price.Term) {
+					continue
+				}
+				prices = append(prices, map[string]interface{}{
+					"product_id":        product.ProductID,
+					"product_name":      product.Name,
+					"sku_id":            sku.SkuID,
+					"sku_name":          sku.Name,
+					"price_id":          price.PriceID,
+					"billing_frequency": price.BillingFrequency,
+					"term":              price.Term,
+					"unit_price":        price.UnitPrice,
+					"currency":          price.Currency,
+				})
+			}
+		}
+	}
+
+	if err := d.Set("prices", prices); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
+
+	return nil
+}
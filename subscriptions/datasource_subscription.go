@@ -8,75 +8,96 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
-// This datasource is used to get information about a known Bytes order
-func datasourceOrder() *schema.Resource {
+// This datasource is used to get information about an existing Azure subscription held under the contract
+func datasourceSubscription() *schema.Resource {
 	return &schema.Resource{
-		ReadContext: datasourceOrderRead,
+		ReadContext: datasourceSubscriptionRead,
 
 		// Initialise all vars for datasource
 		Schema: map[string]*schema.Schema{
-			"order_id": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Existing Bytes order ID",
+			"subscription_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"subscription_id", "friendly_name"},
+				ValidateFunc: validation.StringMatch(azureSubscriptionIDPattern, "must be an Azure subscription ID"),
+				Description:  "The Azure subscription ID to look up",
 			},
-			"id": {
+			"friendly_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"subscription_id", "friendly_name"},
+				Description:  "The friendly name of the subscription to look up. It must match exactly one subscription on the contract",
+			},
+			"order_id": {
 				Type:        schema.TypeInt,
 				Computed:    true,
-				Description: "Existing Bytes ID",
+				Description: "Bytes ID of the order which created the subscription",
 			},
-			"contract_name": {
+			"po_number": {
 				Type:        schema.TypeString,
 				Computed:    true,
-				Description: "Bytes contract name used for the order",
+				Description: "Purchase order number for the subscription",
 			},
-			"subscription_id": {
+			"budget_code": {
 				Type:        schema.TypeString,
 				Computed:    true,
-				Description: "Existing Subscription ID to query",
+				Description: "The budget code used for subscription billing",
 			},
-			"friendly_name": {
+			"division_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The division ID used for subscription billing",
+			},
+			"default_admin": {
 				Type:        schema.TypeString,
 				Computed:    true,
-				Description: "Friendly name of the subscription",
+				Description: "The default admin assigned to the subscription",
 			},
-			"po_number": {
+			"status": {
 				Type:        schema.TypeString,
 				Computed:    true,
-				Description: "Purchase order number for the subscription order",
+				Description: "The billing status of the subscription",
 			},
 			"create_date": {
 				Type:        schema.TypeString,
 				Computed:    true,
-				Description: "The date the order was created",
+				Description: "The date the subscription was created",
 			},
 		},
-		Description: "Get information about a known Bytes order.\n\n" +
-			"Use this data source to get information such as subscription name, id and creation date.",
+		Description: "Get information about an existing Azure subscription held under the Bytes contract.\n\n" +
+			"Use this data source to find the order, billing details and status of a subscription from its Azure subscription ID or friendly name.",
 	}
 }
 
-// datasourceOrderRead is used to read the datasource and set the schema
-func datasourceOrderRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+// datasourceSubscriptionRead is used to read the datasource and set the schema
+func datasourceSubscriptionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*client.Client)
 
-	orderID := d.Get("order_id").(string)
-	order, err := c.GetOrderDetails(orderID)
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("failed to get order with id %s: %s", orderID, err))
+	var subscription *client.Subscription
+	var err error
+	if subscriptionID := d.Get("subscription_id").(string); subscriptionID != "" {
+		subscription, err = c.GetSubscription(ctx, subscriptionID)
+		if err != nil {
+			return apiErrorDiagnostics(fmt.Sprintf("Unable to get subscription with id %s", subscriptionID), err)
+		}
+	} else {
+		friendlyName := d.Get("friendly_name").(string)
+		subscription, err = c.FindSubscriptionByFriendlyName(ctx, friendlyName)
+		if err != nil {
+			return apiErrorDiagnostics(fmt.Sprintf("Unable to get subscription named %s", friendlyName), err)
+		}
 	}
 
-	d.SetId(fmt.Sprintf("%d", order.ID))
-	d.Set("id", order.ID)
-	d.Set("contract_name", order.ContractName)
-	d.Set("create_date", order.CreateDate)
-
-	if len(order.Items) > 0 {
-		d.Set("subscription_id", order.Items[0].SubscriptionID)
-		d.Set("friendly_name", order.Items[0].FriendlyName)
-		d.Set("po_number", order.Items[0].PONumber)
+	d.SetId(subscription.SubscriptionID)
+	for key, value := range flattenSubscription(*subscription) {
+		if err := d.Set(key, value); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	return nil
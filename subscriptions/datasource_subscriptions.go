@@ -0,0 +1,167 @@
+package subscriptions
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+
+	"terraform-provider-bytesnew/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// This datasource is used to list the Azure subscriptions held under the contract
+func datasourceSubscriptions() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceSubscriptionsRead,
+
+		// Initialise all vars for datasource
+		Schema: map[string]*schema.Schema{
+			"status": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return subscriptions with this status, e.g. `Active`",
+			},
+			"po_number": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return subscriptions using this purchase order number",
+			},
+			"budget_code": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return subscriptions using this budget code",
+			},
+			"division_id": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Only return subscriptions billed to this division",
+			},
+			"friendly_name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Only return subscriptions whose friendly name matches this regular expression",
+			},
+			"subscriptions": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The subscriptions matching every filter",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"subscription_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The subscription ID returned by Azure",
+						},
+						"order_id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Bytes ID of the order which created the subscription",
+						},
+						"friendly_name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Friendly name of the subscription",
+						},
+						"status": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The billing status of the subscription",
+						},
+						"po_number": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Purchase order number for the subscription",
+						},
+						"budget_code": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The budget code used for subscription billing",
+						},
+						"division_id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The division ID used for subscription billing",
+						},
+						"default_admin": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The default admin assigned to the subscription",
+						},
+						"create_date": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The date the subscription was created",
+						},
+					},
+				},
+			},
+		},
+		Description: "List the Azure subscriptions held under the Bytes contract.\n\n" +
+			"Use this data source to discover existing subscriptions, for example to drive `for_each` over them.",
+	}
+}
+
+// datasourceSubscriptionsRead is used to read the datasource and set the schema
+func datasourceSubscriptionsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*client.Client)
+
+	opts := client.ListSubscriptionsOptions{
+		Status:     d.Get("status").(string),
+		PONumber:   d.Get("po_number").(string),
+		BudgetCode: d.Get("budget_code").(string),
+		DivisionID: d.Get("division_id").(int),
+	}
+	subscriptions, err := c.ListSubscriptions(ctx, opts)
+	if err != nil {
+		return apiErrorDiagnostics("Unable to list subscriptions", err)
+	}
+
+	// The API cannot match friendly names by pattern, so that filter is only applied here. The pattern has already been validated
+	var friendlyNameRegex *regexp.Regexp
+	if pattern := d.Get("friendly_name_regex").(string); pattern != "" {
+		friendlyNameRegex = regexp.MustCompile(pattern)
+	}
+
+	result := make([]interface{}, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		if !matchesFilter(opts.Status, subscription.Status) || !matchesFilter(opts.PONumber, subscription.PONumber) ||
+			!matchesFilter(opts.BudgetCode, subscription.BudgetCode) || !matchesIntFilter(opts.DivisionID, subscription.DivisionID) {
+			continue
+		}
+		if friendlyNameRegex != nil && !friendlyNameRegex.MatchString(subscription.FriendlyName) {
+			continue
+		}
+		result = append(result, flattenSubscription(subscription))
+	}
+
+	if err := d.Set("subscriptions", result); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
+
+	return nil
+}
+
+// flattenSubscription converts a subscription to the attributes set by the subscription data sources
+func flattenSubscription(subscription client.Subscription) map[string]interface{} {
+	flattened := map[string]interface{}{
+		"subscription_id": subscription.SubscriptionID,
+		"order_id":        subscription.OrderID,
+		"friendly_name":   subscription.FriendlyName,
+		"status":          subscription.Status,
+		"po_number":       subscription.PONumber,
+		"budget_code":     subscription.BudgetCode,
+		"default_admin":   subscription.PrincipalID,
+		"create_date":     subscription.CreateDate,
+	}
+	if subscription.DivisionID != nil {
+		flattened["division_id"] = *subscription.DivisionID
+	}
+	return flattened
+}
@@ -0,0 +1,57 @@
+package subscriptions
+
+import (
+	"fmt"
+
+	"terraform-provider-bytesnew/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// apiErrorDiagnostics turns an error from the client into a diagnostic, adding a hint for common API failures
+func apiErrorDiagnostics(summary string, err error) diag.Diagnostics {
+	detail := err.Error()
+
+	switch {
+	case client.IsUnauthorized(err):
+		detail += "\n\nThe Bytes API rejected the provider credentials. Check the username, password and identity_api_url."
+	case client.IsForbidden(err):
+		detail += "\n\nThe provider credentials are not allowed to perform this action. Check they have access to the contract_id."
+	case client.IsNotFound(err):
+		detail += "\n\nThe Bytes API could not find the requested object. It may have been removed or belong to a different contract."
+	case client.IsConflict(err):
+		detail += "\n\nThe request conflicts with the current state of the contract, for example another order in progress in the contract basket. Try again once it has completed."
+	}
+
+	return diag.Diagnostics{
+		{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   detail,
+		},
+	}
+}
+
+// provisioningWarning explains that an order was placed but not provisioned in time, and how it will be resumed.
+// It is a warning rather than an error because an errored create taints the resource, and replacing it would order
+// the subscriptions again.
+func provisioningWarning(orderID string, err error, resume string) diag.Diagnostics {
+	return diag.Diagnostics{
+		{
+			Severity: diag.Warning,
+			Summary:  "Subscription is still being provisioned",
+			Detail: fmt.Sprintf("Bytes order %s was placed but its subscriptions were not provisioned in time: %s\n\n"+
+				"The order has been saved to state. %s, no further subscription will be ordered.", orderID, err, resume),
+		},
+	}
+}
+
+// asWarnings downgrades errors to warnings, for failures after an order has been placed which must not taint the resource
+func asWarnings(diags diag.Diagnostics) diag.Diagnostics {
+	for i := range diags {
+		if diags[i].Severity == diag.Error {
+			diags[i].Severity = diag.Warning
+		}
+	}
+	return diags
+}
@@ -0,0 +1,84 @@
+package subscriptions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The list data sources send their filters to the API and apply them again to the results, so they return the same
+// thing whether or not the API honours a filter. Text filters match the whole value ignoring case, as the portal
+// does, and filters the API has no equivalent for, such as regular expressions, are only applied here.
+
+// filterDateLayout is the layout of a filter given as a date without a time
+const filterDateLayout = "2006-01-02"
+
+// matchesFilter reports whether value matches an optional text filter
+func matchesFilter(filter, value string) bool {
+	return filter == "" || strings.EqualFold(filter, value)
+}
+
+// matchesIntFilter reports whether value matches an optional number filter, where 0 is not filtered on
+func matchesIntFilter(filter int, value *int) bool {
+	return filter == 0 || (value != nil && *value == filter)
+}
+
+// createdBetween reports whether createDate falls within the optional after and before filters, which have already been
+// validated by validateDate. A filter without a time covers the whole of that day. A create date which cannot be parsed
+// is kept, leaving it to the filter applied by the API.
+func createdBetween(createDate, after, before string) bool {
+	if after == "" && before == "" {
+		return true
+	}
+	created, err := parseAPITime(createDate)
+	if err != nil {
+		return true
+	}
+	if after != "" {
+		if from, _, err := parseFilterDate(after); err == nil && created.Before(from) {
+			return false
+		}
+	}
+	if before != "" {
+		to, dateOnly, err := parseFilterDate(before)
+		if err == nil && dateOnly {
+			// Up to the end of the day
+			to = to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		if err == nil && created.After(to) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFilterDate parses a date filter, reporting whether it was a date without a time
+func parseFilterDate(value string) (time.Time, bool, error) {
+	if date, err := time.Parse(filterDateLayout, value); err == nil {
+		return date, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// parseAPITime parses a date returned by the API, which may leave out the time zone or the time
+func parseAPITime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", filterDateLayout} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", value)
+}
+
+// validateDate checks a filter is an ISO 8601 date such as 2023-10-13, or an RFC 3339 time
+func validateDate(v interface{}, k string) ([]string, []error) {
+	value, ok := v.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+	if _, _, err := parseFilterDate(value); err != nil {
+		return nil, []error{fmt.Errorf("%s must be a date such as 2023-10-13 or a time such as 2023-10-13T09:00:00Z", k)}
+	}
+	return nil, nil
+}
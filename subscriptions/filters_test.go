@@ -0,0 +1,68 @@
+package subscriptions
+
+import (
+	"testing"
+)
+
+func TestCreatedBetween(t *testing.T) {
+	tests := []struct {
+		name       string
+		createDate string
+		after      string
+		before     string
+		want       bool
+	}{
+		{name: "no filters", createDate: "2023-10-13T09:00:00Z", want: true},
+		{name: "on the after date", createDate: "2023-10-13T00:00:00Z", after: "2023-10-13", want: true},
+		{name: "before the after date", createDate: "2023-10-12T23:59:59Z", after: "2023-10-13", want: false},
+		{name: "during the before date", createDate: "2023-10-13T23:59:59Z", before: "2023-10-13", want: true},
+		{name: "after the before date", createDate: "2023-10-14T00:00:00Z", before: "2023-10-13", want: false},
+		{name: "after the before time", createDate: "2023-10-13T09:00:01Z", before: "2023-10-13T09:00:00Z", want: false},
+		{name: "create date without a time zone", createDate: "2023-10-13T09:00:00", after: "2023-10-13T08:00:00Z", want: true},
+		{name: "create date without a time", createDate: "2023-10-12", after: "2023-10-13", want: false},
+		{name: "unparseable create date is kept", createDate: "13/10/2023", after: "2023-10-14", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := createdBetween(tt.createDate, tt.after, tt.before); got != tt.want {
+				t.Errorf("createdBetween(%q, %q, %q) = %t, want %t", tt.createDate, tt.after, tt.before, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		value  string
+		want   bool
+	}{
+		{filter: "", value: "anything", want: true},
+		{filter: "Active", value: "active", want: true},
+		{filter: "PO-1", value: "PO-10", want: false},
+	}
+	for _, tt := range tests {
+		if got := matchesFilter(tt.filter, tt.value); got != tt.want {
+			t.Errorf("matchesFilter(%q, %q) = %t, want %t", tt.filter, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesIntFilter(t *testing.T) {
+	three, four := 3, 4
+	tests := []struct {
+		filter int
+		value  *int
+		want   bool
+	}{
+		{filter: 0, value: nil, want: true},
+		{filter: 3, value: &three, want: true},
+		{filter: 3, value: &four, want: false},
+		{filter: 3, value: nil, want: false},
+	}
+	for _, tt := range tests {
+		if got := matchesIntFilter(tt.filter, tt.value); got != tt.want {
+			t.Errorf("matchesIntFilter(%d, %v) = %t, want %t", tt.filter, tt.value, got, tt.want)
+		}
+	}
+}
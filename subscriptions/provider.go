@@ -3,11 +3,14 @@ package subscriptions
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"terraform-provider-bytesnew/client"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 // Provider - Initialize all vars for Provider config
@@ -46,13 +49,68 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("BYTES_CONTRACT_ID", nil),
 				Description: "Contract ID used for authentication to API Endpoints",
 			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("BYTES_MAX_RETRIES", client.DefaultRetryConfig.MaxRetries),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of times a failed API request is retried. Only requests which are safe to repeat are retried",
+			},
+			"min_backoff": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("BYTES_MIN_BACKOFF", client.DefaultRetryConfig.MinBackoff.String()),
+				ValidateFunc: validateDuration,
+				Description:  "Time to wait before the first retry of a failed API request, doubling for each retry after it, e.g. `1s`",
+			},
+			"max_backoff": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("BYTES_MAX_BACKOFF", client.DefaultRetryConfig.MaxBackoff.String()),
+				ValidateFunc: validateDuration,
+				Description:  "Maximum time to wait between retries of a failed API request, e.g. `30s`. A Retry-After header from the API takes precedence",
+			},
+			"poll_interval": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("BYTES_POLL_INTERVAL", client.DefaultPollInterval.String()),
+				ValidateFunc: validateDurationAtLeast(time.Second),
+				Description:  "How often to check an order while waiting for its subscription to be provisioned, e.g. `30s`",
+			},
+			"basket_lock_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("BYTES_BASKET_LOCK_FILE", ""),
+				Description: "Path of a file to lock while ordering, so separate Terraform runs on the same machine do not use the contract basket at the same time. The lock does not cover runs on other machines, such as separate CI runners, which should share a single runner or be serialized some other way. Orders within a single run are always serialized. Items left in the basket by an earlier failed run are only removed while this file is locked and once they are more than an hour old, otherwise they must be removed in the Bytes portal",
+			},
+			"abandoned_report_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("BYTES_ABANDONED_REPORT_FILE", ""),
+				Description: "Path of a file to append a line of JSON to for every subscription which is left running when its resource is destroyed, so they can be cancelled or reused later",
+			},
+			"on_existing": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("BYTES_ON_EXISTING", onExistingError),
+				ValidateFunc: validation.StringInSlice([]string{onExistingAdopt, onExistingError, onExistingIgnore}, false),
+				Description: "What `bytesnew_subscription` and `bytesnew_subscription_batch` do when the contract already has a live subscription with the same friendly name and PO number, " +
+					"for example after their state was lost. `adopt` takes it into state instead of ordering, `error` fails without ordering and `ignore` orders another subscription. " +
+					"Replacing a resource with `deletion_policy = \"abandon\"` leaves the old subscription running with the same details, " +
+					"so set `abandoned_report_file` to have abandoned subscriptions skipped, or use `ignore`",
+			},
 		},
 		// Define the function to call the resource.
 		ResourcesMap: map[string]*schema.Resource{
-			"bytesnew_subscription": resourceSubscription(),
+			"bytesnew_subscription":       resourceSubscription(),
+			"bytesnew_subscription_batch": resourceSubscriptionBatch(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"bytesnew_order": datasourceOrder(),
+			"bytesnew_order":         datasourceOrder(),
+			"bytesnew_orders":        datasourceOrders(),
+			"bytesnew_subscription":  datasourceSubscription(),
+			"bytesnew_subscriptions": datasourceSubscriptions(),
+			"bytesnew_products":      datasourceProducts(),
 		},
 		ConfigureContextFunc: providerConfigure,
 	}
@@ -75,7 +133,6 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}
 		tempHost := hVal.(string)
 		identity_api_url = &tempHost
 	}
-	fmt.Printf("Identity API URL is: %s", *identity_api_url)
 
 	var commerce_api_url *string
 	cVal, ok := d.GetOk("commerce_api_url")
@@ -83,36 +140,79 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}
 		tempCommerce := cVal.(string)
 		commerce_api_url = &tempCommerce
 	}
-	fmt.Printf("Commerce API URL is: %s", *commerce_api_url)
+	tflog.Debug(ctx, "Configuring Bytes API client", map[string]interface{}{
+		"identity_api_url": hVal,
+		"commerce_api_url": cVal,
+	})
+
+	// Get retry settings, these have already been validated
+	minBackoff, _ := time.ParseDuration(d.Get("min_backoff").(string))
+	maxBackoff, _ := time.ParseDuration(d.Get("max_backoff").(string))
+	retry := client.RetryConfig{
+		MaxRetries: d.Get("max_retries").(int),
+		MinBackoff: minBackoff,
+		MaxBackoff: maxBackoff,
+	}
+	pollInterval, _ := time.ParseDuration(d.Get("poll_interval").(string))
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
 	// If all values are provided then create client
+	var c *client.Client
+	var err error
 	if (username != "") && (password != "") {
-		c, err := client.NewClient(identity_api_url, commerce_api_url, &username, &password, contract_id)
+		c, err = client.NewClient(ctx, identity_api_url, commerce_api_url, &username, &password, contract_id)
+		if err != nil {
+			return nil, append(diags, apiErrorDiagnostics("Unable to create RestApi Client", err)...)
+		}
+	} else {
+		// if values are missing, then create client and return the response
+		c, err = client.NewClient(ctx, nil, nil, nil, nil, 0)
 		if err != nil {
 			diags = append(diags, diag.Diagnostic{
 				Severity: diag.Error,
 				Summary:  "Unable to create RestApi Client",
-				Detail:   fmt.Sprintf("Something wrong with Provider to create client. Error: %s", err),
+				Detail:   fmt.Sprintf("Unable to authenticate user for authenticated RestApi client: %s", err),
 			})
-
 			return nil, diags
 		}
-
-		return c, diags
 	}
 
-	// if values are missing, then create client and return the response
-	c, err := client.NewClient(nil, nil, nil, nil, 0)
-	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Unable to create RestApi Client",
-			Detail:   fmt.Sprintf("Unable to authenticate user for authenticated RestApi client: %s", err),
-		})
-		return nil, diags
-	}
+	// Apply the provider settings which are not needed to authenticate
+	c.Retry = retry
+	c.PollInterval = pollInterval
+	c.BasketLockFile = d.Get("basket_lock_file").(string)
+	c.OnExisting = d.Get("on_existing").(string)
+	c.AbandonedReportFile = d.Get("abandoned_report_file").(string)
+
 	return c, diags
 }
+
+// Values of the on_existing provider setting
+const (
+	onExistingAdopt  = "adopt"
+	onExistingError  = "error"
+	onExistingIgnore = "ignore"
+)
+
+// validateDuration checks a provider setting can be parsed as a Go duration such as 30s or 5m
+var validateDuration = validateDurationAtLeast(0)
+
+// validateDurationAtLeast checks a provider setting is a Go duration of at least min
+func validateDurationAtLeast(min time.Duration) schema.SchemaValidateFunc {
+	return func(v interface{}, k string) ([]string, []error) {
+		value, ok := v.(string)
+		if !ok {
+			return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, []error{fmt.Errorf("%s must be a duration such as 30s or 5m: %s", k, err)}
+		}
+		if duration < min {
+			return nil, []error{fmt.Errorf("%s must be at least %s", k, min)}
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,145 @@
+package subscriptions
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"terraform-provider-bytesnew/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// abandonedSubscription is one line of the abandoned subscriptions report
+type abandonedSubscription struct {
+	AbandonedAt    string `json:"abandonedAt"`
+	OrderID        string `json:"orderId"`
+	SubscriptionID string `json:"subscriptionId"`
+	FriendlyName   string `json:"friendlyName"`
+}
+
+// reportMu serializes writes to the report file from resources destroyed in parallel
+var reportMu sync.Mutex
+
+// recordAbandonedSubscriptions appends the subscriptions left running by a destroy to the report file, one JSON object per line
+func recordAbandonedSubscriptions(path string, subscriptions []abandonedSubscription) error {
+	reportMu.Lock()
+	defer reportMu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open abandoned subscriptions report: %s", err)
+	}
+	defer f.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, subscription := range subscriptions {
+		subscription.AbandonedAt = now
+		line, err := json.Marshal(subscription)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write abandoned subscriptions report: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// abandonedSubscriptions The subscriptions recorded in the report file, so they are not mistaken for existing subscriptions
+// when a resource is replaced and its old subscription has been left running
+type abandonedSubscriptions []abandonedSubscription
+
+// readAbandonedSubscriptions reads the report file, which may not have been written yet
+func readAbandonedSubscriptions(path string) (abandonedSubscriptions, error) {
+	reportMu.Lock()
+	defer reportMu.Unlock()
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open abandoned subscriptions report: %s", err)
+	}
+	defer f.Close()
+
+	var abandoned abandonedSubscriptions
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var subscription abandonedSubscription
+		if err := json.Unmarshal(scanner.Bytes(), &subscription); err != nil {
+			return nil, fmt.Errorf("failed to read abandoned subscriptions report: %s", err)
+		}
+		abandoned = append(abandoned, subscription)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read abandoned subscriptions report: %s", err)
+	}
+	return abandoned, nil
+}
+
+// has reports whether the subscription of match has been abandoned. Subscriptions abandoned before they were
+// provisioned are recognised by their order
+func (a abandonedSubscriptions) has(match client.OrderItemMatch) bool {
+	for _, subscription := range a {
+		if subscription.SubscriptionID != "" {
+			if strings.EqualFold(subscription.SubscriptionID, match.Item.SubscriptionID) {
+				return true
+			}
+			continue
+		}
+		if subscription.OrderID == strconv.Itoa(match.Order.ID) && strings.EqualFold(subscription.FriendlyName, match.Item.FriendlyName) {
+			return true
+		}
+	}
+	return false
+}
+
+// abandonDiagnostics warns that destroying a resource has left its subscriptions running, and records them in the
+// provider's report file when one is configured. It never returns an error, the resource has already been destroyed.
+func abandonDiagnostics(c *client.Client, subscriptions []abandonedSubscription, hint string) diag.Diagnostics {
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	lines := make([]string, len(subscriptions))
+	for i, subscription := range subscriptions {
+		subscriptionID := subscription.SubscriptionID
+		if subscriptionID == "" {
+			subscriptionID = "(not provisioned yet)"
+		}
+		lines[i] = fmt.Sprintf("- %s, subscription ID %s, Bytes order %s", subscription.FriendlyName, subscriptionID, subscription.OrderID)
+	}
+
+	diags := diag.Diagnostics{
+		{
+			Severity: diag.Warning,
+			Summary:  "Subscription left running",
+			Detail: fmt.Sprintf("Destroying the resource has only removed it from state, these subscriptions are still live and billable:\n%s\n\n%s",
+				strings.Join(lines, "\n"), hint),
+		},
+	}
+
+	if c.AbandonedReportFile != "" {
+		if err := recordAbandonedSubscriptions(c.AbandonedReportFile, subscriptions); err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Unable to record abandoned subscription",
+				Detail:   err.Error(),
+			})
+		}
+	}
+
+	return diags
+}
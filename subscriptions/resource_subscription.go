@@ -3,12 +3,27 @@ package subscriptions
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"terraform-provider-bytesnew/client"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
+// Values of the deletion_policy attribute
+const (
+	deletionPolicyAbandon = "abandon"
+	deletionPolicyCancel  = "cancel"
+)
+
+// azureSubscriptionIDPattern matches an Azure subscription GUID
+var azureSubscriptionIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
 // This resource is used to create a new Azure subscription
 func resourceSubscription() *schema.Resource {
 	return &schema.Resource{
@@ -16,6 +31,24 @@ func resourceSubscription() *schema.Resource {
 		ReadContext:   resourceSubscriptionRead,
 		UpdateContext: resourceSubscriptionUpdate,
 		DeleteContext: resourceSubscriptionDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceSubscriptionImport,
+		},
+		CustomizeDiff: resourceSubscriptionCustomizeDiff,
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceSubscriptionV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceSubscriptionStateUpgradeV0,
+			},
+		},
+		// Create waits for the subscription to be provisioned, which can take a while
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Update: schema.DefaultTimeout(20 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+		},
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:        schema.TypeString,
@@ -26,22 +59,19 @@ func resourceSubscription() *schema.Resource {
 				Type:        schema.TypeString,
 				Required:    true,
 				Computed:    false,
-				ForceNew:    true,
-				Description: "Friendly name of the subscription to create. This is used as the name of the subscription in the Bytes/Azure Portal",
+				Description: "Friendly name of the subscription to create. This is used as the name of the subscription in the Bytes/Azure Portal, and is renamed in place",
 			},
 			"po_number": {
 				Type:        schema.TypeString,
 				Required:    true,
 				Computed:    false,
-				ForceNew:    true,
 				Description: "The PO number which can be used to assign a cost to a purchase for billing purposes",
 			},
 			"default_admin": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				Computed:    false,
-				ForceNew:    true,
-				Description: "The default admin which is assigned to a newly created subscription",
+				Computed:    true,
+				Description: "The default admin which is assigned to the subscription. When not set the admin assigned by Bytes is kept",
 			},
 			"subscription_id": {
 				Type:        schema.TypeString,
@@ -52,51 +82,137 @@ func resourceSubscription() *schema.Resource {
 				Type:        schema.TypeString,
 				Required:    true,
 				Computed:    false,
-				ForceNew:    true,
 				Description: "The budget code to use for subscription billing",
 			},
 			"division_id": {
 				Type:        schema.TypeInt,
 				Optional:    true,
-				Computed:    false,
-				Description: "The division ID to use for subscription billing",
+				Computed:    true,
+				Description: "The division ID to use for subscription billing. It must belong to the contract, if not set the division applied by Bytes is recorded",
+			},
+			"product_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      client.DefaultProductID,
+				ValidateFunc: validation.StringIsNotEmpty,
+				Description:  "The Bytes catalogue product to order",
+			},
+			"sku_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      client.DefaultSkuID,
+				ValidateFunc: validation.StringIsNotEmpty,
+				Description:  "The SKU of the catalogue product to order",
+			},
+			"price_id": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      client.DefaultPriceID,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "The ID of the catalogue price to order the product at, which can be looked up with the `bytesnew_products` data source",
+			},
+			"billing_frequency": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      client.DefaultBillingFrequency,
+				ValidateFunc: validation.StringIsNotEmpty,
+				Description:  "How often the subscription is billed, e.g. `monthly` or `annual`",
+			},
+			"term": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      client.DefaultTerm,
+				ValidateFunc: validation.StringIsNotEmpty,
+				Description:  "The commitment term of the order, e.g. `Perpetual`",
+			},
+			"quantity": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      client.DefaultQuantity,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "The quantity of the product to order",
+			},
+			"deletion_policy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      deletionPolicyAbandon,
+				ValidateFunc: validation.StringInSlice([]string{deletionPolicyAbandon, deletionPolicyCancel}, false),
+				Description:  "What happens to the subscription when the resource is destroyed. `abandon` leaves it running and billable with a warning, `cancel` asks Bytes to cancel it",
+			},
+			"prevent_cancel_if_resources_exist": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Refuse to cancel the subscription while it still holds Azure resources, or when Bytes cannot tell whether it does. Only used when `deletion_policy` is `cancel`",
 			},
 		},
 		Description: "Creates a new Azure subscription.\n\n" +
-			"This resources is intended to be used to create a new Azure subscription",
+			"This resources is intended to be used to create a new Azure subscription. " +
+			"Existing subscriptions can be imported using either the Bytes order ID or the Azure subscription ID. " +
+			"If the subscription is not provisioned within the create timeout, the order is kept in state and the next apply resumes waiting for it. " +
+			"Before ordering, the contract is checked for a subscription with the same friendly name and PO number, see the provider `on_existing` setting. " +
+			"The friendly name, PO number, budget code, default admin and division are changed in place, any other change orders a new subscription. " +
+			"Destroying the resource leaves the subscription running unless `deletion_policy` is set to `cancel`.",
 	}
 }
 
 func resourceSubscriptionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	// Warning or errors can be collected in a slice type
-	var diags diag.Diagnostics
 	c := m.(*client.Client)
 
 	// Prepare the JSON Data for API Payload
-	subscriptionDetails := client.SubscriptionDetails{
-		FriendlyName: d.Get("friendly_name").(string),
-		PONumber:     d.Get("po_number").(string),
-		PrincipalID:  d.Get("default_admin").(string),
-		BudgetCode:   d.Get("budget_code").(string),
-		DivisionID:   d.Get("division_id").(int),
+	subscriptionDetails := expandSubscriptionDetails(d)
+
+	// Look for a subscription which was already ordered with the same details, as ordering again would buy a second one
+	if c.OnExisting != onExistingIgnore {
+		existing, diags := findExistingSubscription(ctx, c, subscriptionDetails)
+		if diags.HasError() {
+			return diags
+		}
+		if existing != nil && c.OnExisting == onExistingAdopt {
+			tflog.Info(ctx, "Adopting existing subscription instead of ordering", map[string]interface{}{
+				"order_id":        existing.Order.ID,
+				"subscription_id": existing.Item.SubscriptionID,
+			})
+			d.SetId(strconv.Itoa(existing.Order.ID))
+			d.Set("subscription_id", existing.Item.SubscriptionID)
+			return resourceSubscriptionRead(ctx, d, m)
+		}
+		if existing != nil {
+			return diag.Diagnostics{
+				{
+					Severity: diag.Error,
+					Summary:  "Subscription already exists",
+					Detail: fmt.Sprintf("Bytes order %d already holds a subscription named %q with PO number %q. "+
+						"Import it with its order ID or Azure subscription ID, or set on_existing = \"adopt\" on the provider to take it into state.\n\n%s",
+						existing.Order.ID, existing.Item.FriendlyName, existing.Item.PONumber, existingReplacementHint),
+				},
+			}
+		}
 	}
 
-	// Call the function create the subscription with payload
-	subscription, err := c.CreateSubscription(subscriptionDetails)
+	// Call the function to order the subscription with payload
+	checkout, err := c.OrderSubscription(ctx, subscriptionDetails)
 
 	// If error, print it out
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  fmt.Sprintf("Something wrong with Provider to Delete record: %s", err),
-			Detail:   fmt.Sprintf("Something wrong with Provider to create record: %s", err),
-		})
-		return diags
+		return apiErrorDiagnostics("Unable to create subscription", err)
 	}
 
-	d.SetId(fmt.Sprintf("%d", subscription.ID))
-	d.Set("contract_name", subscription.ContractName)
-	d.Set("create_date", subscription.CreateDate)
+	// The subscription has been bought, so keep the order ID in state from here on.
+	// Any failure below must not error, as a tainted resource would be replaced by a second paid order.
+	d.SetId(fmt.Sprintf("%d", checkout.ID))
+
+	subscription, err := c.WaitForSubscription(ctx, d.Id())
+	if err != nil {
+		// ctx has usually expired by now, so leave refreshing the rest of the state to the next plan
+		return provisioningWarning(d.Id(), err, "The next plan will show an update which resumes waiting for it")
+	}
 
 	if len(subscription.Items) > 0 {
 		d.Set("subscription_id", subscription.Items[0].SubscriptionID)
@@ -105,37 +221,309 @@ func resourceSubscriptionCreate(ctx context.Context, d *schema.ResourceData, m i
 		d.Set("default_admin", subscription.Items[0].PrincipalID)
 	}
 	// Call the resourceSubscriptionRead function
-	resourceSubscriptionRead(ctx, d, m)
-
-	return nil
+	return asWarnings(resourceSubscriptionRead(ctx, d, m))
 }
 
 func resourceSubscriptionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
+	c := m.(*client.Client)
+
+	// The resource ID is the Bytes order ID, so refresh from the order
+	order, err := c.GetOrderDetails(ctx, d.Id())
+	if client.IsNotFound(err) {
+		tflog.Warn(ctx, "Bytes order no longer exists, removing from state", map[string]interface{}{"order_id": d.Id()})
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return apiErrorDiagnostics(fmt.Sprintf("Unable to read order with id %s", d.Id()), err)
+	}
+
+	// Find the item for this subscription, if it has gone then plan a re-create
+	subscriptionID := d.Get("subscription_id").(string)
+	item := findOrderItem(order, subscriptionID)
+	if item == nil && subscriptionID == "" {
+		// The order is still being processed, keep it so the next apply resumes waiting instead of ordering again
+		tflog.Info(ctx, "Bytes order has no items yet, keeping it in state", map[string]interface{}{"order_id": d.Id()})
+		return diags
+	}
+	if item == nil {
+		tflog.Warn(ctx, "Subscription no longer exists in Bytes order, removing from state", map[string]interface{}{
+			"order_id":        d.Id(),
+			"subscription_id": subscriptionID,
+		})
+		d.SetId("")
+		return diags
+	}
+
+	d.Set("subscription_id", item.SubscriptionID)
+	d.Set("friendly_name", item.FriendlyName)
+	d.Set("po_number", item.PONumber)
+	d.Set("default_admin", item.PrincipalID)
+	if item.DivisionID != nil {
+		d.Set("division_id", *item.DivisionID)
+	}
+
+	// The order item keeps the details it was ordered with, the name, billing details and admin can be changed since
+	// so refresh those from the subscription once it has been provisioned
+	if item.SubscriptionID == "" {
+		return diags
+	}
+	subscription, err := c.GetSubscription(ctx, item.SubscriptionID)
+	if client.IsNotFound(err) {
+		tflog.Warn(ctx, "Subscription no longer exists, removing from state", map[string]interface{}{
+			"order_id":        d.Id(),
+			"subscription_id": item.SubscriptionID,
+		})
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return apiErrorDiagnostics(fmt.Sprintf("Unable to read subscription with id %s", item.SubscriptionID), err)
+	}
+	d.Set("friendly_name", subscription.FriendlyName)
+	d.Set("po_number", subscription.PONumber)
+	d.Set("default_admin", subscription.PrincipalID)
+	d.Set("budget_code", subscription.BudgetCode)
+	if subscription.DivisionID != nil {
+		d.Set("division_id", *subscription.DivisionID)
+	}
+
 	return diags
 }
 
+// resourceSubscriptionImport resolves a Bytes order ID or Azure subscription ID to the order holding the subscription
+func resourceSubscriptionImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	c := m.(*client.Client)
+	importID := d.Id()
+
+	var subscriptionID string
+	if azureSubscriptionIDPattern.MatchString(importID) {
+		// Azure subscription ID, look up the order which created it
+		subscriptionID = importID
+	} else {
+		// Bytes order ID, the order must hold exactly one subscription to know which one to import
+		if _, err := strconv.Atoi(importID); err != nil {
+			return nil, fmt.Errorf("import ID %q must be a Bytes order ID or an Azure subscription ID", importID)
+		}
+
+		order, err := c.GetOrderDetails(ctx, importID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get order with id %s: %w", importID, err)
+		}
+		if len(order.Items) != 1 {
+			return nil, fmt.Errorf("order %s has %d items, import it using the Azure subscription ID instead", importID, len(order.Items))
+		}
+		if order.Items[0].SubscriptionID == "" {
+			return nil, fmt.Errorf("order %s has not finished provisioning a subscription yet", importID)
+		}
+		subscriptionID = order.Items[0].SubscriptionID
+	}
+
+	// The subscription holds the billing details which are not part of the order
+	subscription, err := c.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription with id %s: %w", subscriptionID, err)
+	}
+
+	d.SetId(strconv.Itoa(subscription.OrderID))
+	d.Set("subscription_id", subscription.SubscriptionID)
+	d.Set("friendly_name", subscription.FriendlyName)
+	d.Set("po_number", subscription.PONumber)
+	d.Set("default_admin", subscription.PrincipalID)
+	d.Set("budget_code", subscription.BudgetCode)
+	if subscription.DivisionID != nil {
+		d.Set("division_id", *subscription.DivisionID)
+	}
+
+	// The API does not say which catalogue item was ordered, so assume the default rather than plan a replacement
+	setCatalogueDefaults(d)
+	d.Set("deletion_policy", deletionPolicyAbandon)
+	d.Set("prevent_cancel_if_resources_exist", true)
+
+	// Read is called by Terraform after import to refresh the remaining attributes from the order
+	return []*schema.ResourceData{d}, nil
+}
+
+// expandSubscriptionDetails builds the client SubscriptionDetails from the resource configuration
+func expandSubscriptionDetails(d *schema.ResourceData) client.SubscriptionDetails {
+	return client.SubscriptionDetails{
+		FriendlyName:     d.Get("friendly_name").(string),
+		PONumber:         d.Get("po_number").(string),
+		PrincipalID:      d.Get("default_admin").(string),
+		BudgetCode:       d.Get("budget_code").(string),
+		DivisionID:       d.Get("division_id").(int),
+		ProductID:        d.Get("product_id").(string),
+		SkuID:            d.Get("sku_id").(string),
+		PriceID:          d.Get("price_id").(int),
+		BillingFrequency: d.Get("billing_frequency").(string),
+		Term:             d.Get("term").(string),
+		Quantity:         d.Get("quantity").(int),
+	}
+}
+
+// existingReplacementHint explains the existing subscription error when it is caused by replacing a resource
+const existingReplacementHint = "If this resource is being replaced, the old subscription has been left running by deletion_policy = \"abandon\". " +
+	"Set abandoned_report_file on the provider so abandoned subscriptions are not matched, or on_existing = \"ignore\" to order the replacement."
+
+// findExistingSubscription looks for a subscription on the contract with the same friendly name and current PO number as details,
+// leaving out those recorded as abandoned
+func findExistingSubscription(ctx context.Context, c *client.Client, details client.SubscriptionDetails) (*client.OrderItemMatch, diag.Diagnostics) {
+	matches, err := c.FindOrderItems(ctx, details.FriendlyName, details.PONumber)
+	if err != nil {
+		return nil, apiErrorDiagnostics("Unable to check for an existing subscription", err)
+	}
+
+	// A replaced resource leaves its old subscription running under the abandon deletion policy, which is not an existing
+	// subscription for the new one. They can only be told apart when abandoned subscriptions are recorded
+	if c.AbandonedReportFile != "" {
+		abandoned, err := readAbandonedSubscriptions(c.AbandonedReportFile)
+		if err != nil {
+			return nil, diag.Errorf("Unable to check for an existing subscription: %s", err)
+		}
+		live := matches[:0]
+		for _, match := range matches {
+			if !abandoned.has(match) {
+				live = append(live, match)
+			}
+		}
+		matches = live
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &matches[0], nil
+	}
+
+	orderIDs := make([]string, len(matches))
+	for i, match := range matches {
+		orderIDs[i] = strconv.Itoa(match.Order.ID)
+	}
+	return nil, diag.Diagnostics{
+		{
+			Severity: diag.Error,
+			Summary:  "Several existing subscriptions match",
+			Detail: fmt.Sprintf("Bytes orders %s all hold a subscription named %q with PO number %q. "+
+				"Import the right one with its Azure subscription ID, or set on_existing = \"ignore\" on the provider to order another.",
+				strings.Join(orderIDs, ", "), details.FriendlyName, details.PONumber),
+		},
+	}
+}
+
+// findOrderItem returns the order item holding subscriptionID, or the first item when no subscription ID is known yet
+func findOrderItem(order *client.OrderDetails, subscriptionID string) *client.OrderItem {
+	if len(order.Items) == 0 {
+		return nil
+	}
+	if subscriptionID == "" {
+		return &order.Items[0]
+	}
+	for i := range order.Items {
+		if strings.EqualFold(order.Items[i].SubscriptionID, subscriptionID) {
+			return &order.Items[i]
+		}
+	}
+	return nil
+}
+
+// resourceSubscriptionCustomizeDiff plans an update for orders which were placed but not provisioned before Create gave up,
+// so that the next apply resumes waiting for the subscription
+func resourceSubscriptionCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() != "" && d.Get("subscription_id").(string) == "" {
+		return d.SetNewComputed("subscription_id")
+	}
+	return nil
+}
+
 func resourceSubscriptionUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*client.Client)
 
-	subscriptionDetails := client.SubscriptionDetails{
-		FriendlyName: d.Get("friendly_name").(string),
-		PONumber:     d.Get("po_number").(string),
-		PrincipalID:  d.Get("default_admin").(string),
-		BudgetCode:   d.Get("budget_code").(string),
-		DivisionID:   d.Get("division_id").(int),
+	// Resume waiting for an order which was not provisioned before Create gave up
+	if d.Get("subscription_id").(string) == "" {
+		subscription, err := c.WaitForSubscription(ctx, d.Id())
+		if err != nil {
+			return apiErrorDiagnostics(fmt.Sprintf("Subscription for order %s is still being provisioned", d.Id()), err)
+		}
+		d.Set("subscription_id", subscription.Items[0].SubscriptionID)
+	}
+
+	// Nothing else to change when only resuming, or when only the settings kept in state have changed
+	if !d.HasChanges("friendly_name", "po_number", "default_admin", "budget_code", "division_id") {
+		return resourceSubscriptionRead(ctx, d, m)
 	}
 
-	_, err := c.UpdateSubscription(d.Id(), subscriptionDetails)
+	subscriptionDetails := expandSubscriptionDetails(d)
+
+	// Check a new division belongs to the contract before moving charges to it
+	if d.HasChange("division_id") && subscriptionDetails.DivisionID != 0 {
+		if err := c.ValidateDivision(ctx, subscriptionDetails.DivisionID); err != nil {
+			return apiErrorDiagnostics("Invalid division_id", err)
+		}
+	}
+
+	// The resource ID is the order, the update goes to the subscription it provisioned
+	subscriptionID := d.Get("subscription_id").(string)
+	_, err := c.UpdateSubscription(ctx, subscriptionID, client.NewSubscriptionUpdatePayload(subscriptionDetails))
 	if err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiagnostics(fmt.Sprintf("Unable to update subscription with id %s", subscriptionID), err)
 	}
 
 	return resourceSubscriptionRead(ctx, d, m)
 }
 
 func resourceSubscriptionDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	// No-op, do nothing when deleting, not currently supported by Bytes API
+	c := meta.(*client.Client)
+
+	// Abandon the subscription unless asked to cancel it, it stays live and billable in Bytes
+	if d.Get("deletion_policy").(string) != deletionPolicyCancel {
+		return abandonDiagnostics(c, []abandonedSubscription{
+			{
+				OrderID:        d.Id(),
+				SubscriptionID: d.Get("subscription_id").(string),
+				FriendlyName:   d.Get("friendly_name").(string),
+			},
+		}, "Cancel it in the Bytes portal, or set deletion_policy = \"cancel\" before destroying to have Terraform cancel it.")
+	}
+
+	subscriptionID := d.Get("subscription_id").(string)
+	if subscriptionID == "" {
+		return diag.Errorf("Bytes order %s has not provisioned its subscription yet, so it cannot be cancelled. "+
+			"Apply again to wait for it, or set deletion_policy = \"abandon\" to leave it running", d.Id())
+	}
+
+	subscription, err := c.GetSubscription(ctx, subscriptionID)
+	if client.IsNotFound(err) {
+		tflog.Warn(ctx, "Subscription no longer exists, nothing to cancel", map[string]interface{}{"subscription_id": subscriptionID})
+		return nil
+	}
+	if err != nil {
+		return apiErrorDiagnostics(fmt.Sprintf("Unable to read subscription with id %s", subscriptionID), err)
+	}
+
+	if !subscription.Cancelled() {
+		// Cancelling a subscription which still holds resources would take them down with it
+		if d.Get("prevent_cancel_if_resources_exist").(bool) {
+			if subscription.ResourceCount == nil {
+				return diag.Errorf("Bytes could not say whether subscription %s holds any Azure resources, so it has not been cancelled. "+
+					"Set prevent_cancel_if_resources_exist = false to cancel it anyway", subscriptionID)
+			}
+			if *subscription.ResourceCount > 0 {
+				return diag.Errorf("Subscription %s still holds %d Azure resources, so it has not been cancelled. "+
+					"Remove them first, or set prevent_cancel_if_resources_exist = false to cancel it anyway", subscriptionID, *subscription.ResourceCount)
+			}
+		}
+
+		if err := c.CancelSubscription(ctx, subscriptionID); err != nil {
+			return apiErrorDiagnostics(fmt.Sprintf("Unable to cancel subscription with id %s", subscriptionID), err)
+		}
+	}
+
+	if err := c.WaitForCancellation(ctx, subscriptionID); err != nil {
+		return apiErrorDiagnostics(fmt.Sprintf("Cancellation of subscription with id %s was not acknowledged", subscriptionID), err)
+	}
+
 	return nil
 }
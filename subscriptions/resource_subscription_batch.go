@@ -0,0 +1,287 @@
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"terraform-provider-bytesnew/client"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// This resource is used to order several new Azure subscriptions with a single basket checkout
+func resourceSubscriptionBatch() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSubscriptionBatchCreate,
+		ReadContext:   resourceSubscriptionBatchRead,
+		DeleteContext: resourceSubscriptionBatchDelete,
+		// Create waits for every subscription to be provisioned, which can take a while
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Unique ID assigned by Bytes to the order",
+			},
+			"subscription": {
+				Type:        schema.TypeList,
+				Required:    true,
+				ForceNew:    true,
+				MinItems:    1,
+				Description: "A subscription to include in the order. Friendly names must be unique within the order",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"friendly_name": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validation.StringIsNotEmpty,
+							Description:  "Friendly name of the subscription to create. This is used as the name of the subscription in the Bytes/Azure Portal",
+						},
+						"po_number": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "The PO number which can be used to assign a cost to a purchase for billing purposes",
+						},
+						"default_admin": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Description: "The default admin which is assigned to a newly created subscription",
+						},
+						"budget_code": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "The budget code to use for subscription billing",
+						},
+						"division_id": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							ForceNew:    true,
+							Description: "The division ID to use for subscription billing. It must belong to the contract",
+						},
+						"product_id": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ForceNew:     true,
+							Default:      client.DefaultProductID,
+							ValidateFunc: validation.StringIsNotEmpty,
+							Description:  "The Bytes catalogue product to order",
+						},
+						"sku_id": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ForceNew:     true,
+							Default:      client.DefaultSkuID,
+							ValidateFunc: validation.StringIsNotEmpty,
+							Description:  "The SKU of the catalogue product to order",
+						},
+						"price_id": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ForceNew:     true,
+							Default:      client.DefaultPriceID,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "The ID of the catalogue price to order the product at, which can be looked up with the `bytesnew_products` data source",
+						},
+						"billing_frequency": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ForceNew:     true,
+							Default:      client.DefaultBillingFrequency,
+							ValidateFunc: validation.StringIsNotEmpty,
+							Description:  "How often the subscription is billed, e.g. `monthly` or `annual`",
+						},
+						"term": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ForceNew:     true,
+							Default:      client.DefaultTerm,
+							ValidateFunc: validation.StringIsNotEmpty,
+							Description:  "The commitment term of the order, e.g. `Perpetual`",
+						},
+						"quantity": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ForceNew:     true,
+							Default:      client.DefaultQuantity,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "The quantity of the product to order",
+						},
+					},
+				},
+			},
+			"subscription_ids": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The subscription IDs returned by Azure, keyed by friendly name",
+			},
+		},
+		Description: "Creates several new Azure subscriptions with a single order.\n\n" +
+			"All subscriptions are added to the contract basket and checked out together, raising one purchase order. " +
+			"Any change to the subscriptions orders a new batch, and destroying the resource does not cancel them, it warns which subscriptions are left running. " +
+			"Before ordering, the contract is checked for subscriptions with the same friendly name and PO number, see the provider `on_existing` setting.",
+	}
+}
+
+func resourceSubscriptionBatchCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*client.Client)
+
+	subscriptions := expandBatchSubscriptionDetails(d)
+
+	// Look for subscriptions which were already ordered with the same details, as ordering again would buy them twice
+	if c.OnExisting != onExistingIgnore {
+		orderID, diags := findExistingBatch(ctx, c, subscriptions)
+		if diags.HasError() {
+			return diags
+		}
+		if orderID != 0 {
+			tflog.Info(ctx, "Adopting existing order instead of ordering", map[string]interface{}{"order_id": orderID})
+			d.SetId(strconv.Itoa(orderID))
+			return resourceSubscriptionBatchRead(ctx, d, m)
+		}
+	}
+
+	// Call the function to order every subscription in one checkout
+	checkout, err := c.OrderSubscriptions(ctx, subscriptions)
+	if err != nil {
+		return apiErrorDiagnostics("Unable to create subscriptions", err)
+	}
+
+	// The subscriptions have been bought, so keep the order ID in state from here on
+	d.SetId(fmt.Sprintf("%d", checkout.ID))
+
+	_, err = c.WaitForSubscription(ctx, d.Id())
+	if err != nil {
+		// ctx has usually expired by now, so leave refreshing the rest of the state to the next plan
+		return provisioningWarning(d.Id(), err, "Each refresh picks up the subscriptions provisioned since")
+	}
+
+	return asWarnings(resourceSubscriptionBatchRead(ctx, d, m))
+}
+
+func resourceSubscriptionBatchRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+	c := m.(*client.Client)
+
+	order, err := c.GetOrderDetails(ctx, d.Id())
+	if client.IsNotFound(err) {
+		tflog.Warn(ctx, "Bytes order no longer exists, removing from state", map[string]interface{}{"order_id": d.Id()})
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return apiErrorDiagnostics(fmt.Sprintf("Unable to read order with id %s", d.Id()), err)
+	}
+
+	// Map each provisioned order item back to the subscription block with the same friendly name,
+	// subscriptions still being provisioned are added by a later refresh
+	d.Set("subscription_ids", flattenBatchSubscriptionIDs(order))
+
+	return diags
+}
+
+func resourceSubscriptionBatchDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*client.Client)
+
+	// Nothing is cancelled, the subscriptions stay live and billable in Bytes
+	subscriptionIDs := d.Get("subscription_ids").(map[string]interface{})
+	var abandoned []abandonedSubscription
+	for _, s := range d.Get("subscription").([]interface{}) {
+		friendlyName := s.(map[string]interface{})["friendly_name"].(string)
+		subscriptionID, _ := subscriptionIDs[friendlyName].(string)
+		abandoned = append(abandoned, abandonedSubscription{
+			OrderID:        d.Id(),
+			SubscriptionID: subscriptionID,
+			FriendlyName:   friendlyName,
+		})
+	}
+
+	return abandonDiagnostics(c, abandoned, "Cancel them in the Bytes portal if they are no longer needed.")
+}
+
+// findExistingBatch looks for subscriptions on the contract with the same details as any in the batch. Under the adopt
+// policy it returns the order holding them, which is only possible when every subscription in the batch was ordered together
+func findExistingBatch(ctx context.Context, c *client.Client, subscriptions []client.SubscriptionDetails) (int, diag.Diagnostics) {
+	var lines []string
+	orderIDs := map[int]bool{}
+	for _, details := range subscriptions {
+		existing, diags := findExistingSubscription(ctx, c, details)
+		if diags.HasError() {
+			return 0, diags
+		}
+		if existing == nil {
+			continue
+		}
+		orderIDs[existing.Order.ID] = true
+		lines = append(lines, fmt.Sprintf("- %s with PO number %s, Bytes order %d", details.FriendlyName, details.PONumber, existing.Order.ID))
+	}
+	if len(lines) == 0 {
+		return 0, nil
+	}
+
+	advice := "Set on_existing = \"adopt\" on the provider to take their order into state, or on_existing = \"ignore\" to order them again."
+	if c.OnExisting == onExistingAdopt {
+		if len(lines) == len(subscriptions) && len(orderIDs) == 1 {
+			for orderID := range orderIDs {
+				return orderID, nil
+			}
+		}
+		advice = "A batch can only be adopted when every one of its subscriptions is found in the same Bytes order. " +
+			"Remove the existing subscriptions from the batch, or set on_existing = \"ignore\" on the provider to order them again."
+	}
+	return 0, diag.Diagnostics{
+		{
+			Severity: diag.Error,
+			Summary:  "Subscriptions already exist",
+			Detail: fmt.Sprintf("The contract already holds subscriptions with the same friendly name and PO number as the batch:\n%s\n\n%s\n\n%s",
+				strings.Join(lines, "\n"), advice, existingReplacementHint),
+		},
+	}
+}
+
+// expandBatchSubscriptionDetails builds the client SubscriptionDetails for every subscription block
+func expandBatchSubscriptionDetails(d *schema.ResourceData) []client.SubscriptionDetails {
+	raw := d.Get("subscription").([]interface{})
+	subscriptions := make([]client.SubscriptionDetails, len(raw))
+	for i, r := range raw {
+		s := r.(map[string]interface{})
+		subscriptions[i] = client.SubscriptionDetails{
+			FriendlyName:     s["friendly_name"].(string),
+			PONumber:         s["po_number"].(string),
+			PrincipalID:      s["default_admin"].(string),
+			BudgetCode:       s["budget_code"].(string),
+			DivisionID:       s["division_id"].(int),
+			ProductID:        s["product_id"].(string),
+			SkuID:            s["sku_id"].(string),
+			PriceID:          s["price_id"].(int),
+			BillingFrequency: s["billing_frequency"].(string),
+			Term:             s["term"].(string),
+			Quantity:         s["quantity"].(int),
+		}
+	}
+	return subscriptions
+}
+
+// flattenBatchSubscriptionIDs returns the subscription ID of every provisioned order item, keyed by friendly name
+func flattenBatchSubscriptionIDs(order *client.OrderDetails) map[string]interface{} {
+	subscriptionIDs := map[string]interface{}{}
+	for _, item := range order.Items {
+		if item.SubscriptionID != "" {
+			subscriptionIDs[item.FriendlyName] = item.SubscriptionID
+		}
+	}
+	return subscriptionIDs
+}
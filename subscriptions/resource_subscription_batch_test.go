@@ -0,0 +1,124 @@
+package subscriptions
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"terraform-provider-bytesnew/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestResourceSubscriptionBatchCreateTimeout(t *testing.T) {
+	c := newTestClient(t, pendingOrderHandler(t))
+	d := schema.TestResourceDataRaw(t, resourceSubscriptionBatch().Schema, map[string]interface{}{
+		"subscription": []interface{}{
+			map[string]interface{}{"friendly_name": "examplesub-dev", "po_number": "PO-1", "budget_code": "12345"},
+			map[string]interface{}{"friendly_name": "examplesub-prod", "po_number": "PO-1", "budget_code": "12345"},
+		},
+	})
+
+	// Stand in for the create timeout the SDK puts on ctx, expiring while waiting for provisioning
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	diags := resourceSubscriptionBatchCreate(ctx, d, c)
+	requireNoErrors(t, diags)
+	if len(diags) == 0 {
+		t.Error("expected a warning that the subscriptions are still being provisioned")
+	}
+	if d.Id() != "42" {
+		t.Errorf("ID = %q, want the order ID 42 so refreshes pick up the subscriptions", d.Id())
+	}
+}
+
+func TestResourceSubscriptionBatchCatalogueValidation(t *testing.T) {
+	subscription := resourceSubscriptionBatch().Schema["subscription"].Elem.(*schema.Resource).Schema
+
+	// An empty value would silently fall back to the default catalogue item
+	for key, value := range map[string]interface{}{
+		"product_id":        "",
+		"sku_id":            "",
+		"price_id":          0,
+		"billing_frequency": "",
+		"term":              "",
+		"quantity":          0,
+	} {
+		if _, errs := subscription[key].ValidateFunc(value, key); len(errs) == 0 {
+			t.Errorf("%s = %v was accepted", key, value)
+		}
+	}
+}
+
+func TestResourceSubscriptionBatchCreateExisting(t *testing.T) {
+	batch := []interface{}{
+		map[string]interface{}{"friendly_name": "examplesub-dev", "po_number": "PO-1", "budget_code": "12345"},
+		map[string]interface{}{"friendly_name": "examplesub-prod", "po_number": "PO-1", "budget_code": "12345"},
+	}
+	item := func(friendlyName, subscriptionID string) client.OrderItem {
+		return client.OrderItem{SubscriptionID: subscriptionID, FriendlyName: friendlyName, PONumber: "PO-1"}
+	}
+	subscription := func(friendlyName, subscriptionID string, orderID int) client.Subscription {
+		return client.Subscription{SubscriptionID: subscriptionID, OrderID: orderID, FriendlyName: friendlyName, PONumber: "PO-1", Status: "Active"}
+	}
+
+	tests := []struct {
+		name          string
+		onExisting    string
+		orders        []client.OrderDetails
+		subscriptions []client.Subscription
+		wantErr       bool
+		wantID        string
+	}{
+		{
+			name:          "error when one already exists",
+			onExisting:    onExistingError,
+			orders:        []client.OrderDetails{{ID: 5, Items: []client.OrderItem{item("examplesub-dev", "sub-1")}}},
+			subscriptions: []client.Subscription{subscription("examplesub-dev", "sub-1", 5)},
+			wantErr:       true,
+		},
+		{
+			name:       "adopt the order holding the whole batch",
+			onExisting: onExistingAdopt,
+			orders:     []client.OrderDetails{{ID: 5, Items: []client.OrderItem{item("examplesub-dev", "sub-1"), item("examplesub-prod", "sub-2")}}},
+			subscriptions: []client.Subscription{
+				subscription("examplesub-dev", "sub-1", 5),
+				subscription("examplesub-prod", "sub-2", 5),
+			},
+			wantID: "5",
+		},
+		{
+			name:       "no adopting a batch split across orders",
+			onExisting: onExistingAdopt,
+			orders: []client.OrderDetails{
+				{ID: 5, Items: []client.OrderItem{item("examplesub-dev", "sub-1")}},
+				{ID: 6, Items: []client.OrderItem{item("examplesub-prod", "sub-2")}},
+			},
+			subscriptions: []client.Subscription{
+				subscription("examplesub-dev", "sub-1", 5),
+				subscription("examplesub-prod", "sub-2", 6),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, existingSubscriptionsHandler(tt.orders, tt.subscriptions, func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("unexpected request %s %s, the batch must not be ordered", r.Method, r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			c.OnExisting = tt.onExisting
+			d := schema.TestResourceDataRaw(t, resourceSubscriptionBatch().Schema, map[string]interface{}{"subscription": batch})
+
+			diags := resourceSubscriptionBatchCreate(context.Background(), d, c)
+			if diags.HasError() != tt.wantErr {
+				t.Fatalf("diags = %v, want error %t", diags, tt.wantErr)
+			}
+			if d.Id() != tt.wantID {
+				t.Errorf("ID = %q, want %q", d.Id(), tt.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package subscriptions
+
+import (
+	"context"
+
+	"terraform-provider-bytesnew/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// resourceSubscriptionV0 is the bytesnew_subscription schema before the catalogue item could be configured
+func resourceSubscriptionV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"friendly_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"po_number": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"default_admin": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"subscription_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"budget_code": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"division_id": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+		},
+	}
+}
+
+// resourceSubscriptionStateUpgradeV0 records the catalogue item every version 0 subscription was ordered with, and the
+// defaults of the settings added since, so that existing subscriptions plan no changes when the new attributes appear
+func resourceSubscriptionStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, m interface{}) (map[string]interface{}, error) {
+	rawState["product_id"] = client.DefaultProductID
+	rawState["sku_id"] = client.DefaultSkuID
+	rawState["price_id"] = client.DefaultPriceID
+	rawState["billing_frequency"] = client.DefaultBillingFrequency
+	rawState["term"] = client.DefaultTerm
+	rawState["quantity"] = client.DefaultQuantity
+	rawState["deletion_policy"] = deletionPolicyAbandon
+	rawState["prevent_cancel_if_resources_exist"] = true
+
+	return rawState, nil
+}
+
+// setCatalogueDefaults records the default catalogue item for a subscription whose catalogue item cannot be read back
+func setCatalogueDefaults(d *schema.ResourceData) {
+	d.Set("product_id", client.DefaultProductID)
+	d.Set("sku_id", client.DefaultSkuID)
+	d.Set("price_id", client.DefaultPriceID)
+	d.Set("billing_frequency", client.DefaultBillingFrequency)
+	d.Set("term", client.DefaultTerm)
+	d.Set("quantity", client.DefaultQuantity)
+}
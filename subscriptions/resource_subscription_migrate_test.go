@@ -0,0 +1,87 @@
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"terraform-provider-bytesnew/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestResourceSubscriptionStateUpgradeV0(t *testing.T) {
+	v0State := map[string]interface{}{
+		"id":              "42",
+		"friendly_name":   "examplesub",
+		"po_number":       "PO-1",
+		"default_admin":   "admin@example.com",
+		"subscription_id": "sub-1",
+		"budget_code":     "12345",
+		"division_id":     3,
+	}
+	want := map[string]interface{}{
+		"id":                "42",
+		"friendly_name":     "examplesub",
+		"po_number":         "PO-1",
+		"default_admin":     "admin@example.com",
+		"subscription_id":   "sub-1",
+		"budget_code":       "12345",
+		"division_id":       3,
+		"product_id":        client.DefaultProductID,
+		"sku_id":            client.DefaultSkuID,
+		"price_id":          client.DefaultPriceID,
+		"billing_frequency": client.DefaultBillingFrequency,
+		"term":              client.DefaultTerm,
+		"quantity":          client.DefaultQuantity,
+
+		"deletion_policy":                   deletionPolicyAbandon,
+		"prevent_cancel_if_resources_exist": true,
+	}
+
+	got, err := resourceSubscriptionStateUpgradeV0(context.Background(), v0State, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("upgraded state = %v, want %v", got, want)
+	}
+}
+
+func TestResourceSubscriptionStateUpgradeV0NoChanges(t *testing.T) {
+	// The configuration of a version 0 subscription, which does not set any of the attributes added since
+	config := map[string]interface{}{
+		"friendly_name": "examplesub",
+		"po_number":     "PO-1",
+		"default_admin": "admin@example.com",
+		"budget_code":   "12345",
+		"division_id":   3,
+	}
+	v0State := map[string]interface{}{"id": "42", "subscription_id": "sub-1"}
+	for key, value := range config {
+		v0State[key] = value
+	}
+	upgraded, err := resourceSubscriptionStateUpgradeV0(context.Background(), v0State, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Plan against the upgraded state as Terraform stores it, a flatmap of strings
+	state := &terraform.InstanceState{ID: "42", Attributes: map[string]string{}}
+	for key, value := range upgraded {
+		state.Attributes[key] = fmt.Sprint(value)
+	}
+
+	diff, err := resourceSubscription().Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && len(diff.Attributes) > 0 {
+		changes := map[string]string{}
+		for key, attr := range diff.Attributes {
+			changes[key] = fmt.Sprintf("%q => %q (requires new: %t)", attr.Old, attr.New, attr.RequiresNew)
+		}
+		t.Errorf("upgraded subscription plans changes: %v", changes)
+	}
+}
@@ -0,0 +1,251 @@
+package subscriptions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"terraform-provider-bytesnew/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// newTestClient returns a Client for a fake Bytes API, which serves tokens and passes every other request to handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *client.Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"test-token","token_type":"Bearer","expires_in":3600}`))
+	})
+	mux.HandleFunc("/", handler)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	username, password := "user", "pass"
+	c, err := client.NewClient(context.Background(), &server.URL, &server.URL, &username, &password, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.PollInterval = 10 * time.Millisecond
+	c.OnExisting = onExistingIgnore
+	return c
+}
+
+// pendingOrderHandler fakes the API for an order which is checked out as order 42 but never provisioned
+func pendingOrderHandler(t *testing.T) http.HandlerFunc {
+	var mu sync.Mutex
+	var basket []client.BasketItem
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/v2/contracts/1/baskets":
+			// Add the item to the basket and echo back everything in it
+			var payload client.BasketPayload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Error(err)
+			}
+			mu.Lock()
+			basket = append(basket, client.BasketItem{
+				ID:                len(basket) + 1,
+				FriendlyName:      payload.FriendlyName,
+				PONumber:          payload.PONumber,
+				ExternalReference: payload.ExternalReference,
+			})
+			json.NewEncoder(w).Encode(client.BasketDetails{ID: 7, Items: basket})
+			mu.Unlock()
+		case r.Method == "POST" && r.URL.Path == "/api/v2/contracts/1/baskets/7/checkout":
+			w.Write([]byte(`{"id":42}`))
+		case r.Method == "GET" && r.URL.Path == "/api/v2/contracts/1/orders/42":
+			w.Write([]byte(`{"id":42,"items":[]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+// existingSubscriptionsHandler fakes a contract already holding orders and subscriptions, passing any other request to next
+func existingSubscriptionsHandler(orders []client.OrderDetails, subscriptions []client.Subscription, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/contracts/1/orders":
+			json.NewEncoder(w).Encode(client.Page[client.OrderDetails]{Items: orders})
+			return
+		case "/api/v2/contracts/1/subscriptions":
+			json.NewEncoder(w).Encode(client.Page[client.Subscription]{Items: subscriptions})
+			return
+		}
+		for _, order := range orders {
+			if r.URL.Path == fmt.Sprintf("/api/v2/contracts/1/orders/%d", order.ID) {
+				json.NewEncoder(w).Encode(order)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// requireNoErrors fails the test if diags hold an error, which would taint a resource with an ID
+func requireNoErrors(t *testing.T, diags diag.Diagnostics) {
+	t.Helper()
+	for _, d := range diags {
+		if d.Severity == diag.Error {
+			t.Fatalf("unexpected error diagnostic: %s: %s", d.Summary, d.Detail)
+		}
+	}
+}
+
+func TestResourceSubscriptionCreateTimeout(t *testing.T) {
+	c := newTestClient(t, pendingOrderHandler(t))
+	d := schema.TestResourceDataRaw(t, resourceSubscription().Schema, map[string]interface{}{
+		"friendly_name": "examplesub",
+		"po_number":     "PO-1",
+		"budget_code":   "12345",
+	})
+
+	// Stand in for the create timeout the SDK puts on ctx, expiring while waiting for provisioning
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	diags := resourceSubscriptionCreate(ctx, d, c)
+	requireNoErrors(t, diags)
+	if len(diags) == 0 {
+		t.Error("expected a warning that the subscription is still being provisioned")
+	}
+	if d.Id() != "42" {
+		t.Errorf("ID = %q, want the order ID 42 so the next apply resumes it", d.Id())
+	}
+	if got := d.Get("subscription_id").(string); got != "" {
+		t.Errorf("subscription_id = %q, want it empty until provisioned", got)
+	}
+}
+
+func TestResourceSubscriptionCreateSkipsAbandoned(t *testing.T) {
+	orders := []client.OrderDetails{{ID: 5, Items: []client.OrderItem{{SubscriptionID: "sub-1", FriendlyName: "examplesub", PONumber: "PO-1"}}}}
+	subscriptions := []client.Subscription{{SubscriptionID: "sub-1", OrderID: 5, FriendlyName: "examplesub", PONumber: "PO-1", Status: "Active"}}
+
+	tests := []struct {
+		name    string
+		report  string
+		wantErr bool
+	}{
+		{name: "not recorded", report: `{"orderId":"5","subscriptionId":"sub-2","friendlyName":"examplesub"}`, wantErr: true},
+		{name: "recorded by subscription ID", report: `{"orderId":"5","subscriptionId":"SUB-1","friendlyName":"examplesub"}`},
+		{name: "recorded before provisioning", report: `{"orderId":"5","subscriptionId":"","friendlyName":"examplesub"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, existingSubscriptionsHandler(orders, subscriptions, pendingOrderHandler(t)))
+			c.OnExisting = onExistingError
+			c.AbandonedReportFile = filepath.Join(t.TempDir(), "abandoned.jsonl")
+			if err := os.WriteFile(c.AbandonedReportFile, []byte(tt.report+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			d := schema.TestResourceDataRaw(t, resourceSubscription().Schema, map[string]interface{}{
+				"friendly_name": "examplesub",
+				"po_number":     "PO-1",
+				"budget_code":   "12345",
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			diags := resourceSubscriptionCreate(ctx, d, c)
+			if diags.HasError() != tt.wantErr {
+				t.Fatalf("diags = %v, want error %t", diags, tt.wantErr)
+			}
+			if !tt.wantErr && d.Id() != "42" {
+				t.Errorf("ID = %q, want the new order 42", d.Id())
+			}
+		})
+	}
+}
+
+func TestResourceSubscriptionReadRefreshesSubscription(t *testing.T) {
+	// The order item keeps the details the subscription was ordered with, which have since been changed in the portal
+	orders := []client.OrderDetails{{ID: 42, Items: []client.OrderItem{{SubscriptionID: "sub-1", FriendlyName: "examplesub", PONumber: "PO-1", PrincipalID: "old@example.com"}}}}
+	c := newTestClient(t, existingSubscriptionsHandler(orders, nil, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/subscriptions/sub-1" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(client.Subscription{
+			SubscriptionID: "sub-1",
+			OrderID:        42,
+			FriendlyName:   "examplesub-renamed",
+			PONumber:       "PO-2",
+			PrincipalID:    "new@example.com",
+			BudgetCode:     "54321",
+		})
+	}))
+	d := schema.TestResourceDataRaw(t, resourceSubscription().Schema, map[string]interface{}{
+		"friendly_name": "examplesub",
+		"po_number":     "PO-1",
+		"budget_code":   "12345",
+	})
+	d.SetId("42")
+	d.Set("subscription_id", "sub-1")
+
+	requireNoErrors(t, resourceSubscriptionRead(context.Background(), d, c))
+	for key, want := range map[string]string{
+		"friendly_name": "examplesub-renamed",
+		"po_number":     "PO-2",
+		"default_admin": "new@example.com",
+		"budget_code":   "54321",
+	} {
+		if got := d.Get(key).(string); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestResourceSubscriptionRenameInPlace(t *testing.T) {
+	r := resourceSubscription()
+	state := &terraform.InstanceState{
+		ID: "42",
+		Attributes: map[string]string{
+			"id":                                "42",
+			"friendly_name":                     "examplesub",
+			"po_number":                         "PO-1",
+			"budget_code":                       "12345",
+			"default_admin":                     "admin@example.com",
+			"subscription_id":                   "sub-1",
+			"division_id":                       "0",
+			"product_id":                        client.DefaultProductID,
+			"sku_id":                            client.DefaultSkuID,
+			"price_id":                          strconv.Itoa(client.DefaultPriceID),
+			"billing_frequency":                 client.DefaultBillingFrequency,
+			"term":                              client.DefaultTerm,
+			"quantity":                          strconv.Itoa(client.DefaultQuantity),
+			"deletion_policy":                   deletionPolicyAbandon,
+			"prevent_cancel_if_resources_exist": "true",
+		},
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"friendly_name": "examplesub-renamed",
+		"po_number":     "PO-1",
+		"budget_code":   "12345",
+	})
+
+	diff, err := r.Diff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff == nil || diff.Attributes["friendly_name"] == nil {
+		t.Fatalf("diff = %v, want friendly_name to change", diff)
+	}
+	if diff.RequiresNew() {
+		t.Errorf("renaming plans a replacement: %v", diff.Attributes)
+	}
+}